@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -14,17 +15,38 @@ import (
 	"github.com/umarquez/cryptocoins-go-challenge/internal/repository"
 	"github.com/umarquez/cryptocoins-go-challenge/internal/service"
 	"github.com/umarquez/cryptocoins-go-challenge/internal/usecase"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
 
 	_ "github.com/umarquez/cryptocoins-go-challenge/docs" // Import generated docs
 )
 
 const dataPath = "./data"
 
+// priceProviderEnv is the environment variable naming the price provider
+// (defaults to Bitso).
+const priceProviderEnv = "PRICE_PROVIDER"
+
+// fxDisabledEnv is the environment variable that disables the fiat
+// conversions of the prices the provider doesn't quote when set to "true".
+const fxDisabledEnv = "FX_DISABLED"
+
+// fxMaxDeviationEnv is the environment variable with the deviation from the
+// FX rate the MXN prices may imply before a mismatch with the USD ones is
+// logged (defaults to 0.02, 2%).
+const fxMaxDeviationEnv = "FX_MAX_DEVIATION"
+
+// adminTokenEnv is the environment variable with the bearer token of the
+// admin endpoints, which aren't served if it is not set.
+const adminTokenEnv = "ADMIN_TOKEN"
+
 // @title CryptoCoins API
 // @version 1.0
 // @description This is a sample server for managing cryptocurrencies.
 // @host localhost:8080
 // @BasePath /api/v1
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
 func main() {
 	m := new(sync.Mutex)
 
@@ -36,11 +58,46 @@ func main() {
 
 	defer dbCnn.Close()
 	cryptoRepo := repository.NewCryptoRepository(dbCnn, m, time.Minute)
-	cryptoService := service.GetCryptoService()
+
+	providerName := os.Getenv(priceProviderEnv)
+	if providerName == "" {
+		providerName = service.BitsoProviderName
+	}
+	provider, err := service.Providers.New(providerName)
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("Quoting prices with %s", provider.Name())
+
+	// The prices the provider doesn't quote are converted from the other
+	// currencies with the Coinbase exchange rates, which the prices it quotes
+	// are checked against too.
+	var crossRateOpts []service.CrossRateOption
+	var cryptoServiceOpts []service.CryptoServiceOption
+	if os.Getenv(fxDisabledEnv) != "true" {
+		fxMaxDeviation := decimal.New(2, 2)
+		if deviation := os.Getenv(fxMaxDeviationEnv); deviation != "" {
+			if fxMaxDeviation, err = decimal.Parse(deviation); err != nil {
+				panic(fmt.Errorf("invalid %s: %w", fxMaxDeviationEnv, err))
+			}
+		}
+
+		fx := service.NewFX(service.NewCoinbaseFXProvider())
+		crossRateOpts = append(crossRateOpts, service.WithFX(fx))
+		cryptoServiceOpts = append(cryptoServiceOpts, service.WithFXCheck(fx, fxMaxDeviation))
+	}
+
+	cryptoService := service.NewCryptoService(service.NewCrossRate(provider, crossRateOpts...), cryptoServiceOpts...)
 	cryptoUseCase := usecase.NewCryptoUseCase(cryptoService, cryptoRepo)
 
 	log.Printf("Server starting at http://localhost:8080")
-	err = http.ListenAndServe(":8080", controller.NewRouter(cryptoUseCase))
+	// The failover chain reports its state through the admin endpoints.
+	var routerOpts []controller.RouterOption
+	if adminToken := os.Getenv(adminTokenEnv); adminToken != "" {
+		providerChain, _ := provider.(controller.ProviderChain)
+		routerOpts = append(routerOpts, controller.WithAdmin(adminToken, providerChain))
+	}
+	err = http.ListenAndServe(":8080", controller.NewRouter(cryptoUseCase, routerOpts...))
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/service"
+)
+
+// ProviderChain reports the state of the price providers chain.
+type ProviderChain interface {
+	State() []service.ProviderState
+}
+
+type AdminController interface {
+	GetProviders(*gin.Context)
+}
+
+type adminController struct {
+	providerChain ProviderChain
+}
+
+// NewAdminController creates the controller of the admin endpoints. The
+// provider chain is nil when the price provider isn't a failover chain.
+func NewAdminController(providerChain ProviderChain) AdminController {
+	return &adminController{
+		providerChain: providerChain,
+	}
+}
+
+// GetProviders godoc
+// @Summary Get the price providers chain
+// @Description Returns the price providers in failover order, with the state of their circuit breakers. The chain is empty when the price provider isn't a failover chain.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} service.ProviderState
+// @Failure 401 {object} nil
+// @Router /admin/providers [get]
+func (ac *adminController) GetProviders(ctx *gin.Context) {
+	if ac.providerChain == nil {
+		ctx.JSON(http.StatusOK, []service.ProviderState{})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ac.providerChain.State())
+}
+
+// AdminAuth rejects the requests without the "Authorization: Bearer <token>"
+// header of the admin token.
+func AdminAuth(token string) gin.HandlerFunc {
+	want := []byte("Bearer " + token)
+	return func(ctx *gin.Context) {
+		got := []byte(ctx.GetHeader("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		ctx.Next()
+	}
+}
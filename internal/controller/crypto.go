@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,8 +14,8 @@ import (
 )
 
 type CryptoUseCase interface {
-	GetAllCryptos() ([]domain.Crypto, error)
-	GetCryptoById(id int) (domain.Crypto, error)
+	GetAllCryptos(ctx context.Context) ([]domain.Crypto, error)
+	GetCryptoById(ctx context.Context, id int) (domain.Crypto, error)
 }
 
 type CryptoController interface {
@@ -42,7 +43,7 @@ func NewCryptoController(cryptoUseCase CryptoUseCase) CryptoController {
 // @Failure 500 {object} nil
 // @Router /cryptos [get]
 func (cc *cryptoController) GetCryptos(ctx *gin.Context) {
-	cryptos, err := cc.cryptoUseCase.GetAllCryptos()
+	cryptos, err := cc.cryptoUseCase.GetAllCryptos(ctx.Request.Context())
 	if err != nil {
 		ctx.AbortWithStatus(http.StatusInternalServerError)
 		return
@@ -80,7 +81,7 @@ func (cc *cryptoController) GetCryptoById(ctx *gin.Context) {
 		return
 	}
 
-	c, err := cc.cryptoUseCase.GetCryptoById(id)
+	c, err := cc.cryptoUseCase.GetCryptoById(ctx.Request.Context(), id)
 	if err != nil {
 		ctx.AbortWithStatus(http.StatusInternalServerError)
 		return
@@ -8,7 +8,31 @@ import (
 	_ "github.com/umarquez/cryptocoins-go-challenge/docs"
 )
 
-func NewRouter(crypto CryptoUseCase) *gin.Engine {
+// RouterOption represents an option to configure the API router.
+type RouterOption func(*routerConfig)
+
+type routerConfig struct {
+	adminToken    string
+	providerChain ProviderChain
+}
+
+// WithAdmin serves the admin endpoints, which require the given token as a
+// bearer token. The provider chain may be nil when the price provider isn't a
+// failover chain. The admin endpoints aren't served if the token is empty.
+func WithAdmin(token string, providerChain ProviderChain) RouterOption {
+	return func(c *routerConfig) {
+		c.adminToken = token
+		c.providerChain = providerChain
+	}
+}
+
+// NewRouter creates the API router.
+func NewRouter(crypto CryptoUseCase, opts ...RouterOption) *gin.Engine {
+	var config routerConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
 	cryptoController := NewCryptoController(crypto)
 	router := gin.Default()
 
@@ -27,6 +51,14 @@ func NewRouter(crypto CryptoUseCase) *gin.Engine {
 			cryptos.GET("/", cryptoController.GetCryptos)
 			cryptos.GET("/:id", cryptoController.GetCryptoById)
 		}
+
+		if config.adminToken != "" {
+			adminController := NewAdminController(config.providerChain)
+			admin := api.Group("/admin", AdminAuth(config.adminToken))
+			{
+				admin.GET("/providers", adminController.GetProviders)
+			}
+		}
 	}
 
 	return router
@@ -56,10 +56,23 @@ var CryptoName = map[CryptoCurrency]string{
 	XRP: "Ripple",
 }
 
+// Value represents the price of a cryptocurrency in a single currency.
+type Value struct {
+	Price string `json:"price"`
+	Path  string `json:"path,omitempty"` // The conversion path of a synthetic price (e.g., XRP→USD→MXN), empty if quoted directly.
+}
+
+// Synthetic reports whether the price was derived through other pairs rather
+// than quoted directly.
+func (v Value) Synthetic() bool {
+	return v.Path != ""
+}
+
 // Price represents the pricing details for a cryptocurrency.
 type Price struct {
-	USD string `json:"usd"`
-	MXN string `json:"mxn"`
+	USD       string            `json:"usd"`
+	MXN       string            `json:"mxn"`
+	Synthetic map[string]string `json:"synthetic,omitempty"` // The conversion paths of the synthetic prices by currency (e.g., mxn: XRP→USD→MXN).
 }
 
 // Crypto represents the core domain entity for a cryptocurrency.
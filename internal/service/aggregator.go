@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// AggregateProviderName is the name the aggregator is registered with.
+const AggregateProviderName = "aggregate"
+
+// defaultAggregateProviders are the providers aggregated when none are
+// configured. They don't need an API key.
+const defaultAggregateProviders = "bitso,coingecko,coinbase"
+
+// defaultMaxDeviation is the default band around the median the quotes must
+// fall in to contribute to the consensus price (2%).
+var defaultMaxDeviation = decimal.New(2, 2)
+
+// ErrNoConsensus represents an error when too few providers quote a pair to
+// compute a consensus price.
+var ErrNoConsensus = errors.New("no consensus price")
+
+func init() {
+	Providers.Register(AggregateProviderName, func() (PriceProvider, error) {
+		var opts []AggregatorOption
+		if method := os.Getenv("AGGREGATE_METHOD"); method != "" {
+			m, err := ParseAggregationMethod(method)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, WithAggregationMethod(m))
+		}
+		if deviation := os.Getenv("AGGREGATE_MAX_DEVIATION"); deviation != "" {
+			d, err := decimal.Parse(deviation)
+			if err != nil {
+				return nil, fmt.Errorf("invalid AGGREGATE_MAX_DEVIATION: %w", err)
+			}
+			opts = append(opts, WithMaxDeviation(d))
+		}
+
+		names := os.Getenv("AGGREGATE_PROVIDERS")
+		if names == "" {
+			names = defaultAggregateProviders
+		}
+		var providers []PriceProvider
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || name == AggregateProviderName {
+				continue
+			}
+			provider, err := Providers.New(name)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		}
+
+		aggregator, err := NewAggregator(providers, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return aggregator, nil
+	})
+}
+
+// AggregationMethod represents how the consensus price is computed from the
+// quotes of the providers.
+type AggregationMethod int
+
+const (
+	// AggregateMedian takes the median of the prices.
+	AggregateMedian AggregationMethod = iota
+	// AggregateVolumeWeighted weights the prices by the volume the providers
+	// report. The quotes without volume don't contribute, unless no quote
+	// has volume, then the median is taken.
+	AggregateVolumeWeighted
+)
+
+var aggregationMethodNames = map[AggregationMethod]string{
+	AggregateMedian:         "median",
+	AggregateVolumeWeighted: "vwap",
+}
+
+func (m AggregationMethod) String() string {
+	if name, ok := aggregationMethodNames[m]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("AggregationMethod(%d)", int(m))
+}
+
+// ParseAggregationMethod returns the method with the given name ("median" or
+// "vwap").
+func ParseAggregationMethod(name string) (AggregationMethod, error) {
+	for method, methodName := range aggregationMethodNames {
+		if strings.EqualFold(name, methodName) {
+			return method, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown aggregation method %q", name)
+}
+
+// Consensus represents a consensus price and the quotes it was computed from.
+type Consensus struct {
+	Quote    Quote            // Quote is the consensus price, its Sources are the contributing providers.
+	Accepted []Quote          // Accepted are the quotes the price was computed from.
+	Rejected []Quote          // Rejected are the quotes that deviated from the median beyond the band.
+	Failed   map[string]error // Failed are the errors of the providers that couldn't quote the pair.
+}
+
+// Aggregator represents a provider that queries several providers in
+// parallel and quotes the consensus of their prices. It can be used as the
+// provider of NewCryptoService as any other provider.
+type Aggregator struct {
+	providers    []PriceProvider
+	method       AggregationMethod
+	maxDeviation decimal.Decimal
+	minSources   int
+}
+
+// AggregatorOption represents an option to configure the aggregator.
+type AggregatorOption func(*Aggregator)
+
+// WithAggregationMethod sets how the consensus price is computed (median by
+// default).
+func WithAggregationMethod(method AggregationMethod) AggregatorOption {
+	return func(a *Aggregator) {
+		a.method = method
+	}
+}
+
+// WithMaxDeviation sets the band around the median the quotes must fall in to
+// contribute, as a fraction of the median (ex. 0.02 for 2%). Zero disables
+// the outlier rejection.
+func WithMaxDeviation(maxDeviation decimal.Decimal) AggregatorOption {
+	return func(a *Aggregator) {
+		a.maxDeviation = maxDeviation
+	}
+}
+
+// WithMinSources sets the number of quotes that must contribute to the
+// consensus price (1 by default).
+func WithMinSources(minSources int) AggregatorOption {
+	return func(a *Aggregator) {
+		a.minSources = minSources
+	}
+}
+
+// NewAggregator creates a provider that quotes the consensus price of the
+// given providers.
+func NewAggregator(providers []PriceProvider, opts ...AggregatorOption) (*Aggregator, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("the aggregator needs at least one provider")
+	}
+
+	a := &Aggregator{
+		providers:    providers,
+		method:       AggregateMedian,
+		maxDeviation: defaultMaxDeviation,
+		minSources:   1,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.maxDeviation.Sign() < 0 {
+		return nil, fmt.Errorf("invalid max deviation %s", a.maxDeviation)
+	}
+	if _, ok := aggregationMethodNames[a.method]; !ok {
+		return nil, fmt.Errorf("invalid aggregation method %s", a.method)
+	}
+
+	return a, nil
+}
+
+func (a *Aggregator) Name() string {
+	return AggregateProviderName
+}
+
+// SupportedPairs returns the pairs quoted by any of the providers.
+func (a *Aggregator) SupportedPairs() []Pair {
+	var pairs []Pair
+	seen := make(map[Pair]bool)
+	for _, provider := range a.providers {
+		for _, pair := range provider.SupportedPairs() {
+			if !seen[pair] {
+				seen[pair] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	return pairs
+}
+
+func (a *Aggregator) GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	consensus, err := a.Consensus(ctx, crypto, currency)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	log.Printf("[%s][%s] %s price %s from %v (rejected %d, failed %d)", crypto, currency, a.method, consensus.Quote.Price, consensus.Quote.Sources, len(consensus.Rejected), len(consensus.Failed))
+
+	return consensus.Quote, nil
+}
+
+// Consensus queries the providers that quote the pair in parallel, drops the
+// quotes that deviate from their median beyond the band and computes the
+// consensus price of the rest.
+func (a *Aggregator) Consensus(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Consensus, error) {
+	var providers []PriceProvider
+	for _, provider := range a.providers {
+		if supportsPair(provider, crypto, currency) {
+			providers = append(providers, provider)
+		}
+	}
+	if len(providers) == 0 {
+		return Consensus{}, fmt.Errorf("%w: %s/%s by %s", ErrUnsupportedPair, crypto, currency, AggregateProviderName)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		quotes  = make([]Quote, len(providers))
+		errs    = make([]error, len(providers))
+		failed  = make(map[string]error)
+		results []Quote
+	)
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider PriceProvider) {
+			defer wg.Done()
+			quotes[i], errs[i] = provider.GetQuote(ctx, crypto, currency)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	for i, provider := range providers {
+		if errs[i] != nil {
+			failed[provider.Name()] = errs[i]
+			continue
+		}
+		results = append(results, quotes[i])
+	}
+
+	if ctx.Err() != nil {
+		return Consensus{}, ctx.Err()
+	}
+
+	consensus := Consensus{Failed: failed}
+	if len(results) > 0 {
+		median := medianPrice(results)
+		for _, quote := range results {
+			if a.deviates(quote.Price, median) {
+				consensus.Rejected = append(consensus.Rejected, quote)
+				continue
+			}
+			consensus.Accepted = append(consensus.Accepted, quote)
+		}
+	}
+
+	if len(consensus.Accepted) == 0 || len(consensus.Accepted) < a.minSources {
+		return consensus, fmt.Errorf("%w: %s/%s quoted by %d of %d providers (%d rejected, failed: %v)",
+			ErrNoConsensus, crypto, currency, len(consensus.Accepted), len(providers), len(consensus.Rejected), failed)
+	}
+
+	price := medianPrice(consensus.Accepted)
+	if a.method == AggregateVolumeWeighted {
+		if vwap, ok := volumeWeightedPrice(consensus.Accepted); ok {
+			price = vwap
+		}
+	}
+
+	consensus.Quote = Quote{
+		Pair:     Pair{Crypto: crypto, Currency: currency},
+		Price:    price,
+		Provider: AggregateProviderName,
+	}
+	for _, quote := range consensus.Accepted {
+		consensus.Quote.Sources = append(consensus.Quote.Sources, quote.Provider)
+		if quote.Time.After(consensus.Quote.Time) {
+			consensus.Quote.Time = quote.Time
+		}
+	}
+
+	return consensus, nil
+}
+
+// deviates reports whether the price is out of the band around the median.
+func (a *Aggregator) deviates(price, median decimal.Decimal) bool {
+	if a.maxDeviation.IsZero() || median.IsZero() {
+		return false
+	}
+
+	band := median.Abs().Mul(a.maxDeviation)
+	return price.Sub(median).Abs().Cmp(band) > 0
+}
+
+// medianPrice returns the median of the prices of the quotes. The median of
+// an even number of prices is the mean of the two middle ones.
+func medianPrice(quotes []Quote) decimal.Decimal {
+	prices := make([]decimal.Decimal, len(quotes))
+	for i, quote := range quotes {
+		prices[i] = quote.Price
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Cmp(prices[j]) < 0
+	})
+
+	middle := len(prices) / 2
+	if len(prices)%2 == 1 {
+		return prices[middle]
+	}
+
+	low, high := prices[middle-1], prices[middle]
+	return low.Add(high).Div(decimal.NewFromInt(2), max(low.Scale(), high.Scale()), decimal.RoundHalfEven)
+}
+
+// volumeWeightedPrice returns the mean of the prices of the quotes weighted
+// by their volume, or false if no quote has volume.
+func volumeWeightedPrice(quotes []Quote) (decimal.Decimal, bool) {
+	var weighted, volume decimal.Decimal
+	var scale int32
+	for _, quote := range quotes {
+		if quote.Volume.Sign() <= 0 {
+			continue
+		}
+		weighted = weighted.Add(quote.Price.Mul(quote.Volume))
+		volume = volume.Add(quote.Volume)
+		scale = max(scale, quote.Price.Scale())
+	}
+
+	if volume.IsZero() {
+		return decimal.Zero, false
+	}
+
+	return weighted.Div(volume, scale, decimal.RoundHalfEven), true
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// staticProvider quotes fixed prices, or fails with err.
+type staticProvider struct {
+	name   string
+	quotes map[Pair]Quote
+	err    error
+}
+
+func newStaticProvider(name string, prices map[Pair]string, volume string) *staticProvider {
+	p := &staticProvider{name: name, quotes: make(map[Pair]Quote)}
+	for pair, price := range prices {
+		quote := Quote{Pair: pair, Price: decimal.MustParse(price), Provider: name, Time: time.Unix(1711356300, 0)}
+		if volume != "" {
+			quote.Volume = decimal.MustParse(volume)
+		}
+		p.quotes[pair] = quote
+	}
+
+	return p
+}
+
+func (p *staticProvider) Name() string {
+	return p.name
+}
+
+func (p *staticProvider) SupportedPairs() []Pair {
+	pairs := make([]Pair, 0, len(p.quotes))
+	for pair := range p.quotes {
+		pairs = append(pairs, pair)
+	}
+
+	return pairs
+}
+
+func (p *staticProvider) GetQuote(_ context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	if p.err != nil {
+		return Quote{}, p.err
+	}
+
+	quote, ok := p.quotes[Pair{Crypto: crypto, Currency: currency}]
+	if !ok {
+		return Quote{}, ErrUnsupportedPair
+	}
+
+	return quote, nil
+}
+
+var btcUsd = Pair{Crypto: domain.BTC, Currency: domain.USD}
+
+func TestAggregator(t *testing.T) {
+	failing := newStaticProvider("failing", map[Pair]string{btcUsd: "1"}, "")
+	failing.err = errors.New("unavailable")
+
+	providers := []PriceProvider{
+		newStaticProvider("a", map[Pair]string{btcUsd: "67100.00"}, "300"),
+		newStaticProvider("b", map[Pair]string{btcUsd: "67200.00"}, "100"),
+		newStaticProvider("c", map[Pair]string{btcUsd: "67150.00"}, ""),
+		newStaticProvider("stale", map[Pair]string{btcUsd: "61000.00"}, "1000"),
+		newStaticProvider("mxn-only", map[Pair]string{{Crypto: domain.BTC, Currency: domain.MXN}: "1195432.10"}, ""),
+		failing,
+	}
+
+	tests := []struct {
+		name         string
+		opts         []AggregatorOption
+		wantPrice    string
+		wantSources  []string
+		wantRejected int
+	}{
+		{
+			name:         "median",
+			wantPrice:    "67150.00",
+			wantSources:  []string{"a", "b", "c"},
+			wantRejected: 1,
+		},
+		{
+			name:         "volume weighted",
+			opts:         []AggregatorOption{WithAggregationMethod(AggregateVolumeWeighted)},
+			wantPrice:    "67125.00",
+			wantSources:  []string{"a", "b", "c"},
+			wantRejected: 1,
+		},
+		{
+			name:        "without outlier rejection",
+			opts:        []AggregatorOption{WithMaxDeviation(decimal.Zero)},
+			wantPrice:   "67125.00",
+			wantSources: []string{"a", "b", "c", "stale"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregator, err := NewAggregator(providers, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewAggregator() error = %v", err)
+			}
+
+			consensus, err := aggregator.Consensus(context.Background(), domain.BTC, domain.USD)
+			if err != nil {
+				t.Fatalf("Consensus() error = %v", err)
+			}
+			if consensus.Quote.Price.String() != tt.wantPrice || consensus.Quote.Provider != AggregateProviderName {
+				t.Errorf("Consensus() price = %s, want %s", consensus.Quote.Price, tt.wantPrice)
+			}
+			if !reflect.DeepEqual(consensus.Quote.Sources, tt.wantSources) {
+				t.Errorf("Consensus() sources = %v, want %v", consensus.Quote.Sources, tt.wantSources)
+			}
+			if len(consensus.Rejected) != tt.wantRejected {
+				t.Errorf("Consensus() rejected = %v, want %d", consensus.Rejected, tt.wantRejected)
+			}
+			if len(consensus.Failed) != 1 || consensus.Failed["failing"] == nil {
+				t.Errorf("Consensus() failed = %v", consensus.Failed)
+			}
+		})
+	}
+}
+
+func TestAggregatorErrors(t *testing.T) {
+	aggregator, _ := NewAggregator([]PriceProvider{
+		newStaticProvider("a", map[Pair]string{btcUsd: "67100.00"}, ""),
+		newStaticProvider("b", map[Pair]string{btcUsd: "70000.00"}, ""),
+	}, WithMinSources(2))
+
+	// Both quotes deviate more than 2% from their median, so none contributes.
+	if _, err := aggregator.GetQuote(context.Background(), domain.BTC, domain.USD); !errors.Is(err, ErrNoConsensus) {
+		t.Errorf("GetQuote() error = %v, want %v", err, ErrNoConsensus)
+	}
+
+	if _, err := aggregator.GetQuote(context.Background(), domain.ETH, domain.USD); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("GetQuote(ETH/USD) error = %v, want %v", err, ErrUnsupportedPair)
+	}
+
+	if _, err := NewAggregator(nil); err == nil {
+		t.Error("NewAggregator() without providers expected an error")
+	}
+
+	if m, err := ParseAggregationMethod("VWAP"); err != nil || m != AggregateVolumeWeighted {
+		t.Errorf("ParseAggregationMethod(VWAP) = %v, %v", m, err)
+	}
+}
+
+func TestAggregatorCryptoService(t *testing.T) {
+	aggregator, _ := NewAggregator([]PriceProvider{
+		newStaticProvider("a", map[Pair]string{btcUsd: "67100.00"}, ""),
+		newStaticProvider("b", map[Pair]string{btcUsd: "67200.00"}, ""),
+	})
+
+	// The aggregator serves the crypto service as any other provider.
+	value, err := newCryptoService(aggregator).GetValue(context.Background(), domain.BTC, domain.USD)
+	if err != nil || value.Price != "67150.00" || value.Synthetic() {
+		t.Errorf("GetValue() = %+v, %v, want 67150.00", value, err)
+	}
+}
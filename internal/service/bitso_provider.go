@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/bitso_client"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// BitsoProviderName is the name the Bitso provider is registered with.
+const BitsoProviderName = "bitso"
+
+// bitsoBridgePairs are the books quoted besides our cryptos, which the cross
+// rates are derived through.
+var bitsoBridgePairs = []Pair{
+	{Crypto: "USD", Currency: domain.MXN},
+	{Crypto: "USDT", Currency: domain.MXN},
+}
+
+// tickersCacheTTL is the time the tickers fetched from the API are reused.
+const tickersCacheTTL = 5 * time.Second
+
+func init() {
+	Providers.Register(BitsoProviderName, func() (PriceProvider, error) {
+		// A single client shares the rate limiter between the concurrent requests.
+		return NewBitsoProvider(bitso_client.NewClient()), nil
+	})
+}
+
+type bitsoProvider struct {
+	cache  quoteCache
+	client bitso_client.Client
+}
+
+// NewBitsoProvider creates a provider that quotes the Bitso books with the
+// given client (ex. a client replaying recorded responses in the tests).
+func NewBitsoProvider(client bitso_client.Client) PriceProvider {
+	return &bitsoProvider{
+		cache:  quoteCache{ttl: tickersCacheTTL},
+		client: client,
+	}
+}
+
+func (p *bitsoProvider) Name() string {
+	return BitsoProviderName
+}
+
+func (p *bitsoProvider) SupportedPairs() []Pair {
+	pairs := make([]Pair, 0, len(domain.Cryptos)*len(domain.Currencies)+len(bitsoBridgePairs))
+	for _, crypto := range domain.Cryptos {
+		for _, currency := range domain.Currencies {
+			pairs = append(pairs, Pair{Crypto: crypto, Currency: currency})
+		}
+	}
+
+	return append(pairs, bitsoBridgePairs...)
+}
+
+func (p *bitsoProvider) GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	if !supportsPair(p, crypto, currency) {
+		return Quote{}, fmt.Errorf("%w: %s/%s by %s", ErrUnsupportedPair, crypto, currency, BitsoProviderName)
+	}
+
+	quote, ok, err := p.cache.get(ctx, Pair{Crypto: crypto, Currency: currency}, p.fetch)
+	if err != nil {
+		return Quote{}, err
+	}
+	if !ok {
+		book := fmt.Sprintf("%s_%s", strings.ToLower(string(crypto)), strings.ToLower(string(currency)))
+		return Quote{}, fmt.Errorf("failed to fetch %s value: %w", book, bitso_client.ErrUnknownBook)
+	}
+
+	return quote, nil
+}
+
+// fetch gets the tickers of every book in a single request and returns their
+// last prices.
+func (p *bitsoProvider) fetch(ctx context.Context) (map[Pair]Quote, error) {
+	var tickers map[bitso_client.TickerName]bitso_client.Ticker
+	err := fetchWithRetries(ctx, BitsoProviderName, bitso_client.Retryable, func(ctx context.Context) error {
+		var err error
+		tickers, err = p.client.GetAllTickersContext(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make(map[Pair]Quote, len(tickers))
+	for name, ticker := range tickers {
+		crypto, currency, found := strings.Cut(string(name), "_")
+		if !found {
+			continue
+		}
+		pair := Pair{
+			Crypto:   domain.CryptoCurrency(strings.ToUpper(crypto)),
+			Currency: domain.Currency(strings.ToUpper(currency)),
+		}
+		quotes[pair] = Quote{
+			Pair:     pair,
+			Price:    ticker.Payload.Last,
+			Volume:   ticker.Payload.Volume.Mul(ticker.Payload.Last).Round(2, decimal.RoundHalfEven),
+			Provider: BitsoProviderName,
+			Time:     ticker.Payload.CreatedAt,
+		}
+	}
+
+	return quotes, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen represents an error when a provider is skipped because its
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState represents the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request until the cool-down is over.
+	CircuitOpen
+	// CircuitHalfOpen lets a single trial request through, which closes the
+	// circuit if it succeeds or opens it again if it fails.
+	CircuitHalfOpen
+)
+
+var circuitStateNames = map[CircuitState]string{
+	CircuitClosed:   "closed",
+	CircuitOpen:     "open",
+	CircuitHalfOpen: "half-open",
+}
+
+func (s CircuitState) String() string {
+	if name, ok := circuitStateNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// CircuitBreakerState represents a snapshot of a circuit breaker.
+type CircuitBreakerState struct {
+	State    CircuitState
+	Failures int       // Failures is the number of consecutive failures.
+	OpenedAt time.Time // OpenedAt is when the circuit was opened, zero if it is closed.
+	RetryAt  time.Time // RetryAt is when an open circuit lets a trial request through.
+}
+
+// CircuitBreaker represents a circuit breaker that stops sending requests to
+// a failing provider for a while. It is safe for concurrent use.
+type CircuitBreaker struct {
+	mutex            sync.Mutex
+	failureThreshold int
+	coolDown         time.Duration
+	state            CircuitState
+	failures         int
+	openedAt         time.Time
+	trialInFlight    bool
+	now              func() time.Time
+}
+
+// NewCircuitBreaker creates a closed circuit breaker that opens after the
+// given number of consecutive failures, and lets a trial request through
+// once the cool-down is over.
+func NewCircuitBreaker(failureThreshold int, coolDown time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		coolDown:         coolDown,
+		now:              time.Now,
+	}
+}
+
+// Allow returns nil if a request may be sent, or ErrCircuitOpen if it must be
+// skipped. Every allowed request must be reported with Success, Failure or
+// Release.
+func (b *CircuitBreaker) Allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == CircuitOpen && !b.now().Before(b.openedAt.Add(b.coolDown)) {
+		b.state = CircuitHalfOpen
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		// A single trial request at a time.
+		if b.trialInFlight {
+			return ErrCircuitOpen
+		}
+		b.trialInFlight = true
+	}
+
+	return nil
+}
+
+// Success reports an allowed request succeeded, closing the circuit.
+func (b *CircuitBreaker) Success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.openedAt = time.Time{}
+	b.trialInFlight = false
+}
+
+// Failure reports an allowed request failed. The circuit opens if the trial
+// request failed or the failures reached the threshold. A failure reported
+// once the circuit is open (ex. a slow request sent before it opened) doesn't
+// extend the cool-down.
+func (b *CircuitBreaker) Failure() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures++
+	switch b.state {
+	case CircuitClosed:
+		if b.failures >= b.failureThreshold {
+			b.state = CircuitOpen
+			b.openedAt = b.now()
+		}
+	case CircuitHalfOpen:
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+	b.trialInFlight = false
+}
+
+// Release reports an allowed request tells nothing about the provider (ex.
+// the caller canceled it), leaving the circuit as it is.
+func (b *CircuitBreaker) Release() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.trialInFlight = false
+}
+
+// State returns a snapshot of the circuit breaker.
+func (b *CircuitBreaker) State() CircuitBreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	state := CircuitBreakerState{State: b.state, Failures: b.failures, OpenedAt: b.openedAt}
+	if b.state != CircuitClosed {
+		state.RetryAt = b.openedAt.Add(b.coolDown)
+		if b.state == CircuitOpen && !b.now().Before(state.RetryAt) {
+			state.State = CircuitHalfOpen
+		}
+	}
+
+	return state
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 3, 25, 8, 45, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	// A success resets the consecutive failures.
+	for _, fail := range []bool{true, false, true} {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Allow() error = %v", err)
+		}
+		if fail {
+			breaker.Failure()
+		} else {
+			breaker.Success()
+		}
+	}
+	if state := breaker.State(); state.State != CircuitClosed || state.Failures != 1 {
+		t.Errorf("State() = %+v, want closed with 1 failure", state)
+	}
+
+	_ = breaker.Allow()
+	breaker.Failure()
+	if state := breaker.State(); state.State != CircuitOpen || !state.OpenedAt.Equal(now) || !state.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("State() = %+v, want open", state)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Allow() of an open circuit error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// After the cool-down a single trial request goes through, and its
+	// failure opens the circuit again.
+	now = now.Add(time.Minute)
+	if state := breaker.State(); state.State != CircuitHalfOpen {
+		t.Errorf("State() after the cool-down = %v, want %v", state.State, CircuitHalfOpen)
+	}
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() of the trial request error = %v", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Allow() during the trial request error = %v, want %v", err, ErrCircuitOpen)
+	}
+	breaker.Failure()
+	if state := breaker.State(); state.State != CircuitOpen || !state.OpenedAt.Equal(now) {
+		t.Errorf("State() after a failed trial = %+v, want open", state)
+	}
+
+	// A released trial lets the next one through, and its success closes the
+	// circuit.
+	now = now.Add(time.Minute)
+	_ = breaker.Allow()
+	breaker.Release()
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() after a released trial error = %v", err)
+	}
+	breaker.Success()
+	if state := breaker.State(); state.State != CircuitClosed || state.Failures != 0 || !state.OpenedAt.IsZero() {
+		t.Errorf("State() after a successful trial = %+v, want closed", state)
+	}
+}
+
+func TestCircuitBreakerLateFailure(t *testing.T) {
+	opened := time.Date(2024, 3, 25, 8, 45, 0, 0, time.UTC)
+	now := opened
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	// Two requests are sent while the circuit is closed, and the first
+	// failure opens it.
+	_ = breaker.Allow()
+	_ = breaker.Allow()
+	breaker.Failure()
+
+	// The failure of the slow one doesn't extend the cool-down.
+	now = now.Add(30 * time.Second)
+	breaker.Failure()
+	if state := breaker.State(); state.State != CircuitOpen || !state.OpenedAt.Equal(opened) || state.Failures != 2 {
+		t.Errorf("State() after a late failure = %+v, want open since %v", state, opened)
+	}
+
+	now = opened.Add(time.Minute)
+	if err := breaker.Allow(); err != nil {
+		t.Errorf("Allow() after the cool-down error = %v", err)
+	}
+}
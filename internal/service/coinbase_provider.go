@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// CoinbaseProviderName is the name the Coinbase provider is registered with.
+const CoinbaseProviderName = "coinbase"
+
+const coinbaseUrl = "https://api.coinbase.com/v2"
+
+// coinbaseCacheTTL is the time the prices fetched from the API are reused.
+const coinbaseCacheTTL = 10 * time.Second
+
+// coinbaseQuoteCurrency is the currency the spot prices are fetched in. The
+// prices in the other currencies are derived with the exchange rates.
+const coinbaseQuoteCurrency = domain.USD
+
+func init() {
+	Providers.Register(CoinbaseProviderName, func() (PriceProvider, error) {
+		return NewCoinbaseProvider(), nil
+	})
+}
+
+type coinbaseProvider struct {
+	cache      quoteCache
+	httpClient *http.Client
+	baseUrl    string
+}
+
+// CoinbaseOption represents an option to configure the Coinbase provider.
+type CoinbaseOption func(*coinbaseProvider)
+
+// WithCoinbaseBaseUrl sets the base URL of the API (ex. a local stand-in in
+// the tests).
+func WithCoinbaseBaseUrl(baseUrl string) CoinbaseOption {
+	return func(p *coinbaseProvider) {
+		p.baseUrl = strings.TrimSuffix(baseUrl, "/")
+	}
+}
+
+// WithCoinbaseHTTPClient sets the HTTP client used for the requests.
+func WithCoinbaseHTTPClient(client *http.Client) CoinbaseOption {
+	return func(p *coinbaseProvider) {
+		p.httpClient = client
+	}
+}
+
+// NewCoinbaseProvider creates a provider that quotes the cryptos with the
+// public Coinbase API: the spot prices in USD, converted to the other
+// currencies with the USD exchange rates.
+func NewCoinbaseProvider(opts ...CoinbaseOption) PriceProvider {
+	return newCoinbaseProvider(opts...)
+}
+
+func newCoinbaseProvider(opts ...CoinbaseOption) *coinbaseProvider {
+	p := &coinbaseProvider{
+		cache:      quoteCache{ttl: coinbaseCacheTTL},
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		baseUrl:    coinbaseUrl,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *coinbaseProvider) Name() string {
+	return CoinbaseProviderName
+}
+
+// SupportedPairs returns the pairs of our cryptos, plus the exchange rates of
+// USD to the other currencies, which the cross rates are derived through.
+func (p *coinbaseProvider) SupportedPairs() []Pair {
+	pairs := make([]Pair, 0, (len(domain.Cryptos)+1)*len(domain.Currencies))
+	for _, crypto := range domain.Cryptos {
+		for _, currency := range domain.Currencies {
+			pairs = append(pairs, Pair{Crypto: crypto, Currency: currency})
+		}
+	}
+	for _, currency := range domain.Currencies {
+		if currency != coinbaseQuoteCurrency {
+			pairs = append(pairs, Pair{Crypto: domain.CryptoCurrency(coinbaseQuoteCurrency), Currency: currency})
+		}
+	}
+
+	return pairs
+}
+
+func (p *coinbaseProvider) GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	if !supportsPair(p, crypto, currency) {
+		return Quote{}, fmt.Errorf("%w: %s/%s by %s", ErrUnsupportedPair, crypto, currency, CoinbaseProviderName)
+	}
+
+	quote, ok, err := p.cache.get(ctx, Pair{Crypto: crypto, Currency: currency}, p.fetch)
+	if err != nil {
+		return Quote{}, err
+	}
+	if !ok {
+		return Quote{}, fmt.Errorf("%s returned no %s/%s price", CoinbaseProviderName, crypto, currency)
+	}
+
+	return quote, nil
+}
+
+// coinbaseSpotPrice represents the spot price response.
+type coinbaseSpotPrice struct {
+	Data struct {
+		Amount   decimal.Decimal `json:"amount"`
+		Base     string          `json:"base"`
+		Currency string          `json:"currency"`
+	} `json:"data"`
+}
+
+// coinbaseExchangeRates represents the exchange rates response: the amount of
+// every currency one unit of the given currency buys.
+type coinbaseExchangeRates struct {
+	Data struct {
+		Currency string                     `json:"currency"`
+		Rates    map[string]decimal.Decimal `json:"rates"`
+	} `json:"data"`
+}
+
+// fetch gets the spot price of every crypto and the exchange rates, and
+// derives the prices in every currency.
+func (p *coinbaseProvider) fetch(ctx context.Context) (map[Pair]Quote, error) {
+	var rates coinbaseExchangeRates
+	header, err := p.get(ctx, "/exchange-rates?currency="+url.QueryEscape(string(coinbaseQuoteCurrency)), &rates)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make(map[Pair]Quote, (len(domain.Cryptos)+1)*len(domain.Currencies))
+	for _, currency := range domain.Currencies {
+		if rate, ok := rates.Data.Rates[string(currency)]; ok && currency != coinbaseQuoteCurrency {
+			pair := Pair{Crypto: domain.CryptoCurrency(coinbaseQuoteCurrency), Currency: currency}
+			quotes[pair] = Quote{Pair: pair, Price: rate, Provider: CoinbaseProviderName, Time: responseTime(header)}
+		}
+	}
+
+	for _, crypto := range domain.Cryptos {
+		var spot coinbaseSpotPrice
+		header, err := p.get(ctx, fmt.Sprintf("/prices/%s-%s/spot", crypto, coinbaseQuoteCurrency), &spot)
+		if err != nil {
+			return nil, err
+		}
+		updatedAt := responseTime(header)
+
+		for _, currency := range domain.Currencies {
+			price := spot.Data.Amount
+			if currency != coinbaseQuoteCurrency {
+				rate, ok := rates.Data.Rates[string(currency)]
+				if !ok {
+					continue
+				}
+				price = price.Mul(rate).Round(max(spot.Data.Amount.Scale(), 2), decimal.RoundHalfEven)
+			}
+
+			pair := Pair{Crypto: crypto, Currency: currency}
+			quotes[pair] = Quote{Pair: pair, Price: price, Provider: CoinbaseProviderName, Time: updatedAt}
+		}
+	}
+
+	return quotes, nil
+}
+
+// responseTime returns the time a response was sent at, which is the upstream
+// time of the prices as they carry no timestamp.
+func responseTime(header http.Header) time.Time {
+	sentAt, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return time.Now().UTC()
+	}
+
+	return sentAt.UTC()
+}
+
+// get sends a request to the API, retrying it in case of a transient error.
+func (p *coinbaseProvider) get(ctx context.Context, path string, out any) (http.Header, error) {
+	var header http.Header
+	err := fetchWithRetries(ctx, CoinbaseProviderName, retryable, func(ctx context.Context) error {
+		var err error
+		header, err = getJSON(ctx, p.httpClient, CoinbaseProviderName, p.baseUrl+path, nil, out, decodeCoinbaseError)
+		return err
+	})
+
+	return header, err
+}
+
+// decodeCoinbaseError details an unsuccessful response with the first error
+// of its body.
+func decodeCoinbaseError(body []byte, providerErr *ProviderError) {
+	var resp struct {
+		Errors []struct {
+			Id      string `json:"id"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Errors) == 0 {
+		return
+	}
+
+	providerErr.Code = resp.Errors[0].Id
+	providerErr.Message = resp.Errors[0].Message
+}
+
+type coinbaseFXProvider struct {
+	api *coinbaseProvider
+}
+
+// NewCoinbaseFXProvider creates an FX provider with the exchange rates of the
+// public Coinbase API.
+func NewCoinbaseFXProvider(opts ...CoinbaseOption) FXProvider {
+	return &coinbaseFXProvider{api: newCoinbaseProvider(opts...)}
+}
+
+func (p *coinbaseFXProvider) Name() string {
+	return CoinbaseProviderName
+}
+
+func (p *coinbaseFXProvider) GetRate(ctx context.Context, base, quote domain.Currency) (FXRate, error) {
+	var rates coinbaseExchangeRates
+	header, err := p.api.get(ctx, "/exchange-rates?currency="+url.QueryEscape(string(base)), &rates)
+	if err != nil {
+		return FXRate{}, err
+	}
+
+	rate, ok := rates.Data.Rates[string(quote)]
+	if !ok || !strings.EqualFold(rates.Data.Currency, string(base)) {
+		return FXRate{}, fmt.Errorf("%w: %s/%s by %s", ErrUnsupportedCurrency, base, quote, CoinbaseProviderName)
+	}
+
+	return FXRate{Base: base, Quote: quote, Rate: rate, Source: CoinbaseProviderName, Time: responseTime(header)}, nil
+}
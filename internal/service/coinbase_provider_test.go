@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+)
+
+// newCoinbaseServer serves the recorded spot prices and exchange rates, sent
+// at the given time.
+func newCoinbaseServer(t *testing.T, date time.Time) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var name string
+		switch {
+		case r.URL.Path == "/exchange-rates":
+			name = "exchange_rates_" + r.URL.Query().Get("currency") + ".json"
+		case strings.HasPrefix(r.URL.Path, "/prices/") && strings.HasSuffix(r.URL.Path, "/spot"):
+			name = "spot_" + strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/prices/"), "/spot") + ".json"
+		}
+
+		body, err := os.ReadFile(filepath.Join("testdata", "coinbase", name))
+		if name == "" || err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"id":"not_found","message":"Invalid currency"}]}`))
+			return
+		}
+
+		w.Header().Set("Date", date.Format(http.TimeFormat))
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCoinbaseProvider(t *testing.T) {
+	date := time.Date(2024, 3, 25, 8, 45, 12, 0, time.UTC)
+	provider := NewCoinbaseProvider(WithCoinbaseBaseUrl(newCoinbaseServer(t, date).URL))
+
+	ctx := context.Background()
+	tests := []struct {
+		crypto   domain.CryptoCurrency
+		currency domain.Currency
+		want     string
+	}{
+		{domain.BTC, domain.USD, "67187.34"},
+		{domain.BTC, domain.MXN, "1127920.91"},
+		{domain.ETH, domain.USD, "3525.86"},
+		{domain.XRP, domain.MXN, "10.5527"},
+	}
+	for _, tt := range tests {
+		quote, err := provider.GetQuote(ctx, tt.crypto, tt.currency)
+		if err != nil || quote.Price.String() != tt.want || quote.Provider != CoinbaseProviderName || !quote.Time.Equal(date) {
+			t.Errorf("GetQuote(%s/%s) = %+v, %v, want %s", tt.crypto, tt.currency, quote, err, tt.want)
+		}
+	}
+
+	if _, err := provider.GetQuote(ctx, domain.BTC, "EUR"); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("GetQuote(BTC/EUR) error = %v, want %v", err, ErrUnsupportedPair)
+	}
+}
+
+func TestCoinbaseProviderErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[{"id":"not_found","message":"Invalid currency"}]}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewCoinbaseProvider(WithCoinbaseBaseUrl(srv.URL)).GetQuote(context.Background(), domain.BTC, domain.USD)
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Code != "not_found" || providerErr.Message != "Invalid currency" || providerErr.Retryable() {
+		t.Errorf("GetQuote() error = %v, want a not found error", err)
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// CoinGeckoProviderName is the name the CoinGecko provider is registered with.
+const CoinGeckoProviderName = "coingecko"
+
+const (
+	coinGeckoPublicUrl = "https://api.coingecko.com/api/v3"
+	coinGeckoProUrl    = "https://pro-api.coingecko.com/api/v3"
+)
+
+// coinGeckoCacheTTL is the time the prices fetched from the API are reused.
+// CoinGecko refreshes them every minute at most on the demo tier.
+const coinGeckoCacheTTL = 30 * time.Second
+
+// CoinGeckoTier represents the CoinGecko plan an API key belongs to.
+type CoinGeckoTier string
+
+const (
+	CoinGeckoDemo CoinGeckoTier = "demo" // CoinGeckoDemo for the free demo keys.
+	CoinGeckoPro  CoinGeckoTier = "pro"  // CoinGeckoPro for the paid plans.
+)
+
+// coinGeckoApiKeyHeaders are the headers the API keys are sent in by tier.
+var coinGeckoApiKeyHeaders = map[CoinGeckoTier]string{
+	CoinGeckoDemo: "x-cg-demo-api-key",
+	CoinGeckoPro:  "x-cg-pro-api-key",
+}
+
+// coinGeckoIds maps our symbols to the CoinGecko coin ids.
+var coinGeckoIds = map[domain.CryptoCurrency]string{
+	domain.BTC: "bitcoin",
+	domain.ETH: "ethereum",
+	domain.XRP: "ripple",
+}
+
+func init() {
+	Providers.Register(CoinGeckoProviderName, func() (PriceProvider, error) {
+		var opts []CoinGeckoOption
+		if key := os.Getenv("COINGECKO_API_KEY"); key != "" {
+			tier := CoinGeckoTier(strings.ToLower(os.Getenv("COINGECKO_API_TIER")))
+			if tier == "" {
+				tier = CoinGeckoDemo
+			}
+			opts = append(opts, WithCoinGeckoAPIKey(tier, key))
+		}
+
+		return NewCoinGeckoProvider(opts...)
+	})
+}
+
+type coinGeckoProvider struct {
+	cache      quoteCache
+	httpClient *http.Client
+	baseUrl    string
+	tier       CoinGeckoTier
+	apiKey     string
+}
+
+// CoinGeckoOption represents an option to configure the CoinGecko provider.
+type CoinGeckoOption func(*coinGeckoProvider)
+
+// WithCoinGeckoAPIKey sends the API key of the given tier in every request.
+// The pro keys are sent to the pro API unless a base URL is given.
+func WithCoinGeckoAPIKey(tier CoinGeckoTier, key string) CoinGeckoOption {
+	return func(p *coinGeckoProvider) {
+		p.tier = tier
+		p.apiKey = key
+	}
+}
+
+// WithCoinGeckoBaseUrl sets the base URL of the API (ex. a local stand-in in
+// the tests).
+func WithCoinGeckoBaseUrl(baseUrl string) CoinGeckoOption {
+	return func(p *coinGeckoProvider) {
+		p.baseUrl = strings.TrimSuffix(baseUrl, "/")
+	}
+}
+
+// WithCoinGeckoHTTPClient sets the HTTP client used for the requests.
+func WithCoinGeckoHTTPClient(client *http.Client) CoinGeckoOption {
+	return func(p *coinGeckoProvider) {
+		p.httpClient = client
+	}
+}
+
+// NewCoinGeckoProvider creates a provider that quotes every crypto in USD and
+// MXN with a single call to the CoinGecko simple price endpoint.
+func NewCoinGeckoProvider(opts ...CoinGeckoOption) (PriceProvider, error) {
+	p := &coinGeckoProvider{
+		cache:      quoteCache{ttl: coinGeckoCacheTTL},
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.apiKey != "" {
+		if _, ok := coinGeckoApiKeyHeaders[p.tier]; !ok {
+			return nil, fmt.Errorf("unknown CoinGecko tier %q", p.tier)
+		}
+	}
+
+	if p.baseUrl == "" {
+		p.baseUrl = coinGeckoPublicUrl
+		if p.apiKey != "" && p.tier == CoinGeckoPro {
+			p.baseUrl = coinGeckoProUrl
+		}
+	}
+
+	return p, nil
+}
+
+func (p *coinGeckoProvider) Name() string {
+	return CoinGeckoProviderName
+}
+
+func (p *coinGeckoProvider) SupportedPairs() []Pair {
+	pairs := make([]Pair, 0, len(domain.Cryptos)*len(domain.Currencies))
+	for _, crypto := range domain.Cryptos {
+		if _, ok := coinGeckoIds[crypto]; !ok {
+			continue
+		}
+		for _, currency := range domain.Currencies {
+			pairs = append(pairs, Pair{Crypto: crypto, Currency: currency})
+		}
+	}
+
+	return pairs
+}
+
+func (p *coinGeckoProvider) GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	if !supportsPair(p, crypto, currency) {
+		return Quote{}, fmt.Errorf("%w: %s/%s by %s", ErrUnsupportedPair, crypto, currency, CoinGeckoProviderName)
+	}
+
+	quote, ok, err := p.cache.get(ctx, Pair{Crypto: crypto, Currency: currency}, p.fetch)
+	if err != nil {
+		return Quote{}, err
+	}
+	if !ok {
+		return Quote{}, fmt.Errorf("%s returned no %s/%s price", CoinGeckoProviderName, crypto, currency)
+	}
+
+	return quote, nil
+}
+
+// coinGeckoPrices represents the simple price response: the prices and the
+// "<currency>_24h_vol" volumes by currency (lowercase) by coin id, plus the
+// "last_updated_at" timestamp.
+type coinGeckoPrices map[string]map[string]decimal.Decimal
+
+// fetch gets the prices of every crypto in every currency in a single request.
+func (p *coinGeckoProvider) fetch(ctx context.Context) (map[Pair]Quote, error) {
+	ids := make([]string, 0, len(coinGeckoIds))
+	for _, crypto := range domain.Cryptos {
+		if id, ok := coinGeckoIds[crypto]; ok {
+			ids = append(ids, id)
+		}
+	}
+	currencies := make([]string, 0, len(domain.Currencies))
+	for _, currency := range domain.Currencies {
+		currencies = append(currencies, strings.ToLower(string(currency)))
+	}
+
+	query := url.Values{}
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("vs_currencies", strings.Join(currencies, ","))
+	query.Set("include_24hr_vol", "true")
+	query.Set("include_last_updated_at", "true")
+
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set(coinGeckoApiKeyHeaders[p.tier], p.apiKey)
+	}
+
+	var prices coinGeckoPrices
+	err := fetchWithRetries(ctx, CoinGeckoProviderName, retryable, func(ctx context.Context) error {
+		_, err := getJSON(ctx, p.httpClient, CoinGeckoProviderName, p.baseUrl+"/simple/price?"+query.Encode(), header, &prices, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make(map[Pair]Quote, len(ids)*len(currencies))
+	for crypto, id := range coinGeckoIds {
+		coin, ok := prices[id]
+		if !ok {
+			continue
+		}
+
+		var updatedAt time.Time
+		if seconds, ok := coin["last_updated_at"]; ok {
+			updatedAt = time.Unix(int64(seconds.Float64()), 0).UTC()
+		}
+
+		for _, currency := range domain.Currencies {
+			key := strings.ToLower(string(currency))
+			price, ok := coin[key]
+			if !ok {
+				continue
+			}
+			pair := Pair{Crypto: crypto, Currency: currency}
+			quotes[pair] = Quote{Pair: pair, Price: price, Volume: coin[key+"_24h_vol"], Provider: CoinGeckoProviderName, Time: updatedAt}
+		}
+	}
+
+	return quotes, nil
+}
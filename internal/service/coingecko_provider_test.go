@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+)
+
+// newCoinGeckoServer serves the recorded simple price response, checking the
+// requests are the ones the provider is expected to send.
+func newCoinGeckoServer(t *testing.T, apiKeyHeader, apiKey string) (*httptest.Server, *int) {
+	t.Helper()
+
+	body, err := os.ReadFile("testdata/coingecko_simple_price.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		if r.URL.Path != "/simple/price" || query.Get("ids") != "bitcoin,ethereum,ripple" || query.Get("vs_currencies") != "mxn,usd" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if apiKeyHeader != "" && r.Header.Get(apiKeyHeader) != apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":{"error_code":10002,"error_message":"API Key Missing"}}`))
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func TestCoinGeckoProvider(t *testing.T) {
+	srv, requests := newCoinGeckoServer(t, "x-cg-demo-api-key", "demo-key")
+
+	provider, err := NewCoinGeckoProvider(WithCoinGeckoBaseUrl(srv.URL), WithCoinGeckoAPIKey(CoinGeckoDemo, "demo-key"))
+	if err != nil {
+		t.Fatalf("NewCoinGeckoProvider() error = %v", err)
+	}
+	if len(provider.SupportedPairs()) != 6 {
+		t.Errorf("SupportedPairs() = %v", provider.SupportedPairs())
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		crypto   domain.CryptoCurrency
+		currency domain.Currency
+		want     string
+	}{
+		{domain.BTC, domain.MXN, "1195432.1"},
+		{domain.BTC, domain.USD, "67187.34"},
+		{domain.ETH, domain.MXN, "62750.8"},
+		{domain.XRP, domain.USD, "0.628613"},
+	}
+	for _, tt := range tests {
+		quote, err := provider.GetQuote(ctx, tt.crypto, tt.currency)
+		if err != nil || quote.Price.String() != tt.want || quote.Provider != CoinGeckoProviderName {
+			t.Errorf("GetQuote(%s/%s) = %+v, %v, want %s", tt.crypto, tt.currency, quote, err, tt.want)
+		}
+	}
+
+	quote, _ := provider.GetQuote(ctx, domain.BTC, domain.MXN)
+	if want := time.Unix(1711356300, 0).UTC(); !quote.Time.Equal(want) {
+		t.Errorf("GetQuote() time = %v, want %v", quote.Time, want)
+	}
+	if quote.Volume.String() != "500345678901.2" {
+		t.Errorf("GetQuote() volume = %s, want 500345678901.2", quote.Volume)
+	}
+
+	// Every price comes from a single request.
+	if *requests != 1 {
+		t.Errorf("server got %d requests, want 1", *requests)
+	}
+
+	if _, err = provider.GetQuote(ctx, domain.BTC, "EUR"); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("GetQuote(BTC/EUR) error = %v, want %v", err, ErrUnsupportedPair)
+	}
+}
+
+func TestCoinGeckoProviderErrors(t *testing.T) {
+	srv, requests := newCoinGeckoServer(t, "x-cg-pro-api-key", "pro-key")
+
+	// A demo key is sent in the wrong header, so the request is rejected
+	// without retries.
+	provider, err := NewCoinGeckoProvider(WithCoinGeckoBaseUrl(srv.URL), WithCoinGeckoAPIKey(CoinGeckoDemo, "pro-key"))
+	if err != nil {
+		t.Fatalf("NewCoinGeckoProvider() error = %v", err)
+	}
+
+	_, err = provider.GetQuote(context.Background(), domain.BTC, domain.USD)
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusUnauthorized || providerErr.Retryable() {
+		t.Errorf("GetQuote() error = %v, want an unauthorized error", err)
+	}
+	if *requests != 1 {
+		t.Errorf("server got %d requests, want 1", *requests)
+	}
+
+	provider, _ = NewCoinGeckoProvider(WithCoinGeckoBaseUrl(srv.URL), WithCoinGeckoAPIKey(CoinGeckoPro, "pro-key"))
+	if _, err = provider.GetQuote(context.Background(), domain.BTC, domain.USD); err != nil {
+		t.Errorf("GetQuote() with a pro key error = %v", err)
+	}
+
+	if _, err = NewCoinGeckoProvider(WithCoinGeckoAPIKey("enterprise", "key")); err == nil {
+		t.Error("NewCoinGeckoProvider() with an unknown tier expected an error")
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// CoinMarketCapProviderName is the name the CoinMarketCap provider is
+// registered with.
+const CoinMarketCapProviderName = "coinmarketcap"
+
+const coinMarketCapUrl = "https://pro-api.coinmarketcap.com"
+
+// coinMarketCapCacheTTL is the time the prices fetched from the API are
+// reused. Every request spends credits, and CoinMarketCap refreshes the
+// prices every minute.
+const coinMarketCapCacheTTL = time.Minute
+
+// ErrMissingAPIKey represents an error when a provider that requires an API
+// key is created without one.
+var ErrMissingAPIKey = errors.New("missing API key")
+
+// coinMarketCapIds maps our symbols to the CoinMarketCap ids. The ids are
+// used instead of the symbols because several coins share a symbol.
+var coinMarketCapIds = map[domain.CryptoCurrency]string{
+	domain.BTC: "1",
+	domain.ETH: "1027",
+	domain.XRP: "52",
+}
+
+// coinMarketCapErrors maps the CoinMarketCap error codes to the service errors.
+var coinMarketCapErrors = map[int]error{
+	1001: ErrProviderUnauthorized,  // API key invalid.
+	1002: ErrProviderUnauthorized,  // API key missing.
+	1003: ErrProviderUnauthorized,  // Plan requires payment.
+	1004: ErrProviderUnauthorized,  // Plan payment expired.
+	1005: ErrProviderUnauthorized,  // API key required.
+	1006: ErrProviderUnauthorized,  // Plan not authorized for the endpoint.
+	1007: ErrProviderUnauthorized,  // API key disabled.
+	1008: ErrProviderRateLimited,   // Minute rate limit reached.
+	1009: ErrProviderQuotaExceeded, // Daily credits exhausted.
+	1010: ErrProviderQuotaExceeded, // Monthly credits exhausted.
+	1011: ErrProviderRateLimited,   // IP rate limit reached.
+}
+
+func init() {
+	Providers.Register(CoinMarketCapProviderName, func() (PriceProvider, error) {
+		provider, err := NewCoinMarketCapProvider(os.Getenv("COINMARKETCAP_API_KEY"))
+		if err != nil {
+			return nil, err
+		}
+
+		return provider, nil
+	})
+}
+
+// CoinMarketCapUsage represents the API usage of a CoinMarketCap provider
+// since it was created.
+type CoinMarketCapUsage struct {
+	Requests      int64     // Requests is the number of requests answered by the API.
+	Credits       int64     // Credits is the number of credits the requests were charged.
+	LastRequestAt time.Time // LastRequestAt is the time of the last answered request.
+}
+
+// CoinMarketCapProvider represents a provider that quotes the cryptos with
+// the CoinMarketCap API, tracking the credits it spends.
+type CoinMarketCapProvider struct {
+	cache      quoteCache
+	httpClient *http.Client
+	baseUrl    string
+	apiKey     string
+
+	usageMutex sync.Mutex
+	usage      CoinMarketCapUsage
+}
+
+// CoinMarketCapOption represents an option to configure the CoinMarketCap
+// provider.
+type CoinMarketCapOption func(*CoinMarketCapProvider)
+
+// WithCoinMarketCapBaseUrl sets the base URL of the API (ex. the sandbox API
+// or a local stand-in in the tests).
+func WithCoinMarketCapBaseUrl(baseUrl string) CoinMarketCapOption {
+	return func(p *CoinMarketCapProvider) {
+		p.baseUrl = strings.TrimSuffix(baseUrl, "/")
+	}
+}
+
+// WithCoinMarketCapHTTPClient sets the HTTP client used for the requests.
+func WithCoinMarketCapHTTPClient(client *http.Client) CoinMarketCapOption {
+	return func(p *CoinMarketCapProvider) {
+		p.httpClient = client
+	}
+}
+
+// NewCoinMarketCapProvider creates a provider that quotes every crypto in USD
+// and MXN with a single call to the latest quotes endpoint.
+func NewCoinMarketCapProvider(apiKey string, opts ...CoinMarketCapOption) (*CoinMarketCapProvider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: set COINMARKETCAP_API_KEY", ErrMissingAPIKey)
+	}
+
+	p := &CoinMarketCapProvider{
+		cache:      quoteCache{ttl: coinMarketCapCacheTTL},
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		baseUrl:    coinMarketCapUrl,
+		apiKey:     apiKey,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
+}
+
+func (p *CoinMarketCapProvider) Name() string {
+	return CoinMarketCapProviderName
+}
+
+func (p *CoinMarketCapProvider) SupportedPairs() []Pair {
+	pairs := make([]Pair, 0, len(domain.Cryptos)*len(domain.Currencies))
+	for _, crypto := range domain.Cryptos {
+		if _, ok := coinMarketCapIds[crypto]; !ok {
+			continue
+		}
+		for _, currency := range domain.Currencies {
+			pairs = append(pairs, Pair{Crypto: crypto, Currency: currency})
+		}
+	}
+
+	return pairs
+}
+
+func (p *CoinMarketCapProvider) GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	if !supportsPair(p, crypto, currency) {
+		return Quote{}, fmt.Errorf("%w: %s/%s by %s", ErrUnsupportedPair, crypto, currency, CoinMarketCapProviderName)
+	}
+
+	quote, ok, err := p.cache.get(ctx, Pair{Crypto: crypto, Currency: currency}, p.fetch)
+	if err != nil {
+		return Quote{}, err
+	}
+	if !ok {
+		return Quote{}, fmt.Errorf("%s returned no %s/%s price", CoinMarketCapProviderName, crypto, currency)
+	}
+
+	return quote, nil
+}
+
+// Usage returns the API usage of the provider.
+func (p *CoinMarketCapProvider) Usage() CoinMarketCapUsage {
+	p.usageMutex.Lock()
+	defer p.usageMutex.Unlock()
+
+	return p.usage
+}
+
+// coinMarketCapStatus represents the status block of every response.
+type coinMarketCapStatus struct {
+	Timestamp    time.Time `json:"timestamp"`
+	ErrorCode    int       `json:"error_code"`
+	ErrorMessage string    `json:"error_message"`
+	CreditCount  int64     `json:"credit_count"`
+	Notice       string    `json:"notice"`
+}
+
+// coinMarketCapQuotes represents the latest quotes response.
+type coinMarketCapQuotes struct {
+	Status coinMarketCapStatus `json:"status"`
+	Data   map[string]struct {
+		Symbol string `json:"symbol"`
+		Quote  map[string]struct {
+			Price       decimal.Decimal `json:"price"`
+			Volume24h   decimal.Decimal `json:"volume_24h"`
+			LastUpdated time.Time       `json:"last_updated"`
+		} `json:"quote"`
+	} `json:"data"`
+}
+
+// fetch gets the prices of every crypto in every currency in a single request.
+func (p *CoinMarketCapProvider) fetch(ctx context.Context) (map[Pair]Quote, error) {
+	ids := make([]string, 0, len(coinMarketCapIds))
+	for _, crypto := range domain.Cryptos {
+		if id, ok := coinMarketCapIds[crypto]; ok {
+			ids = append(ids, id)
+		}
+	}
+	currencies := make([]string, 0, len(domain.Currencies))
+	for _, currency := range domain.Currencies {
+		currencies = append(currencies, string(currency))
+	}
+
+	query := url.Values{}
+	query.Set("id", strings.Join(ids, ","))
+	query.Set("convert", strings.Join(currencies, ","))
+
+	header := http.Header{}
+	header.Set("X-CMC_PRO_API_KEY", p.apiKey)
+
+	var resp coinMarketCapQuotes
+	err := fetchWithRetries(ctx, CoinMarketCapProviderName, retryable, func(ctx context.Context) error {
+		resp = coinMarketCapQuotes{}
+		_, err := getJSON(ctx, p.httpClient, CoinMarketCapProviderName, p.baseUrl+"/v2/cryptocurrency/quotes/latest?"+query.Encode(), header, &resp, p.decodeError)
+		if err != nil {
+			return err
+		}
+		// A successful response may still carry an error in its status.
+		if providerErr := p.handleStatus(http.StatusOK, resp.Status); providerErr != nil {
+			return providerErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make(map[Pair]Quote, len(ids)*len(currencies))
+	for crypto, id := range coinMarketCapIds {
+		coin, ok := resp.Data[id]
+		if !ok {
+			continue
+		}
+		for _, currency := range domain.Currencies {
+			quote, ok := coin.Quote[string(currency)]
+			if !ok {
+				continue
+			}
+			pair := Pair{Crypto: crypto, Currency: currency}
+			quotes[pair] = Quote{Pair: pair, Price: quote.Price, Volume: quote.Volume24h, Provider: CoinMarketCapProviderName, Time: quote.LastUpdated}
+		}
+	}
+
+	return quotes, nil
+}
+
+// track adds the credits charged for a request to the usage.
+func (p *CoinMarketCapProvider) track(status coinMarketCapStatus) {
+	p.usageMutex.Lock()
+	defer p.usageMutex.Unlock()
+
+	p.usage.Requests++
+	p.usage.Credits += status.CreditCount
+	p.usage.LastRequestAt = status.Timestamp
+	if p.usage.LastRequestAt.IsZero() {
+		p.usage.LastRequestAt = time.Now()
+	}
+}
+
+// handleStatus tracks the credits charged for a response, logs its notice and
+// returns the error described by its status block, if any. Every response
+// carries a status block, successful or not.
+func (p *CoinMarketCapProvider) handleStatus(statusCode int, status coinMarketCapStatus) *ProviderError {
+	p.track(status)
+	if status.Notice != "" {
+		log.Printf("%s notice: %s", CoinMarketCapProviderName, status.Notice)
+	}
+	if status.ErrorCode == 0 {
+		return nil
+	}
+
+	return p.statusError(statusCode, status)
+}
+
+// decodeError details an unsuccessful response with its status block.
+func (p *CoinMarketCapProvider) decodeError(body []byte, providerErr *ProviderError) {
+	var resp struct {
+		Status coinMarketCapStatus `json:"status"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return
+	}
+
+	if statusErr := p.handleStatus(providerErr.StatusCode, resp.Status); statusErr != nil {
+		*providerErr = *statusErr
+	}
+}
+
+// statusError returns the error described by the status block.
+func (p *CoinMarketCapProvider) statusError(statusCode int, status coinMarketCapStatus) *ProviderError {
+	err, ok := coinMarketCapErrors[status.ErrorCode]
+	if !ok {
+		err = statusError(statusCode)
+	}
+
+	return &ProviderError{
+		Provider:   CoinMarketCapProviderName,
+		StatusCode: statusCode,
+		Code:       strconv.Itoa(status.ErrorCode),
+		Message:    status.ErrorMessage,
+		Err:        err,
+	}
+}
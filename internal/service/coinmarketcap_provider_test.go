@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/cassette"
+)
+
+func TestCoinMarketCapProvider(t *testing.T) {
+	body, err := os.ReadFile("testdata/coinmarketcap_quotes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/v2/cryptocurrency/quotes/latest" || query.Get("id") != "1,1027,52" || query.Get("convert") != "MXN,USD" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("X-CMC_PRO_API_KEY") != "cmc-key" {
+			t.Errorf("request without the API key: %v", r.Header)
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	provider, err := NewCoinMarketCapProvider("cmc-key", WithCoinMarketCapBaseUrl(srv.URL))
+	if err != nil {
+		t.Fatalf("NewCoinMarketCapProvider() error = %v", err)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		crypto   domain.CryptoCurrency
+		currency domain.Currency
+		want     string
+	}{
+		{domain.BTC, domain.MXN, "1195432.0978642116"},
+		{domain.ETH, domain.USD, "3525.8614031257207"},
+		{domain.XRP, domain.MXN, "10.460081346215874"},
+	}
+	for _, tt := range tests {
+		quote, err := provider.GetQuote(ctx, tt.crypto, tt.currency)
+		if err != nil || quote.Price.String() != tt.want || quote.Provider != CoinMarketCapProviderName || quote.Time.IsZero() {
+			t.Errorf("GetQuote(%s/%s) = %+v, %v, want %s", tt.crypto, tt.currency, quote, err, tt.want)
+		}
+	}
+
+	// Every price comes from a single request, charged 2 credits for the 2
+	// conversions.
+	if usage := provider.Usage(); usage.Requests != 1 || usage.Credits != 2 || usage.LastRequestAt.IsZero() {
+		t.Errorf("Usage() = %+v", usage)
+	}
+
+	if _, err = NewCoinMarketCapProvider(""); !errors.Is(err, ErrMissingAPIKey) {
+		t.Errorf("NewCoinMarketCapProvider() without a key error = %v, want %v", err, ErrMissingAPIKey)
+	}
+}
+
+func TestCoinMarketCapProviderErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		errorCode    int
+		want         error
+		wantRequests int
+	}{
+		{name: "invalid key", status: http.StatusUnauthorized, errorCode: 1001, want: ErrProviderUnauthorized, wantRequests: 1},
+		{name: "plan not authorized", status: http.StatusForbidden, errorCode: 1006, want: ErrProviderUnauthorized, wantRequests: 1},
+		{name: "minute rate limit", status: http.StatusTooManyRequests, errorCode: 1008, want: ErrProviderRateLimited, wantRequests: fetchRetries},
+		{name: "monthly credits", status: http.StatusTooManyRequests, errorCode: 1010, want: ErrProviderQuotaExceeded, wantRequests: 1},
+		{name: "server error", status: http.StatusInternalServerError, errorCode: 500, want: ErrProviderUnavailable, wantRequests: fetchRetries},
+		{name: "daily credits in a successful response", status: http.StatusOK, errorCode: 1009, want: ErrProviderQuotaExceeded, wantRequests: 1},
+		{name: "rate limit in a successful response", status: http.StatusOK, errorCode: 1008, want: ErrProviderRateLimited, wantRequests: fetchRetries},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tt.status)
+				_, _ = fmt.Fprintf(w, `{"status":{"timestamp":"2024-03-25T08:45:12.347Z","error_code":%d,"error_message":"%s","credit_count":1}}`, tt.errorCode, tt.name)
+			}))
+			defer srv.Close()
+
+			provider, _ := NewCoinMarketCapProvider("cmc-key", WithCoinMarketCapBaseUrl(srv.URL))
+			_, err := provider.GetQuote(context.Background(), domain.BTC, domain.USD)
+
+			var providerErr *ProviderError
+			if !errors.Is(err, tt.want) || !errors.As(err, &providerErr) || providerErr.Code != fmt.Sprint(tt.errorCode) || providerErr.Message != tt.name {
+				t.Errorf("GetQuote() error = %v, want %v", err, tt.want)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", requests, tt.wantRequests)
+			}
+			// The failed requests are tracked too.
+			if usage := provider.Usage(); usage.Requests != int64(requests) || usage.Credits != int64(requests) {
+				t.Errorf("Usage() = %+v, want %d requests and credits", usage, requests)
+			}
+		})
+	}
+}
+
+func TestCoinMarketCapCassette(t *testing.T) {
+	body, err := os.ReadFile("testdata/coinmarketcap_quotes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "coinmarketcap.json")
+	rec, err := cassette.New(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatalf("cassette.New() error = %v", err)
+	}
+	provider, err := NewCoinMarketCapProvider("cmc-secret-key",
+		WithCoinMarketCapBaseUrl(srv.URL), WithCoinMarketCapHTTPClient(&http.Client{Transport: rec}))
+	if err != nil {
+		t.Fatalf("NewCoinMarketCapProvider() error = %v", err)
+	}
+	if _, err = provider.GetQuote(context.Background(), domain.BTC, domain.USD); err != nil {
+		t.Fatalf("GetQuote() error = %v", err)
+	}
+	if err = rec.Stop(); err != nil {
+		t.Fatalf("cassette.Stop() error = %v", err)
+	}
+
+	// The recorded API key is redacted.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "cmc-secret-key") {
+		t.Errorf("cassette keeps the API key:\n%s", data)
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+const (
+	// defaultMaxHops is the default number of pairs a synthetic price may be
+	// derived through.
+	defaultMaxHops = 3
+	// maxCrossPaths is the number of paths tried before giving up on a pair.
+	maxCrossPaths = 5
+	// crossRateScale is the number of decimals the inverse rates are computed
+	// with, before the derived price is rounded.
+	crossRateScale = 18
+)
+
+// ErrNoCrossRate represents an error when no conversion path between the
+// crypto and the currency could be quoted.
+var ErrNoCrossRate = errors.New("no cross rate")
+
+// crossLeg represents a step of a conversion path: the pair quoted by the
+// provider, traversed forward (from its crypto to its currency) or inverted,
+// or a fiat conversion of the FX service.
+type crossLeg struct {
+	pair    Pair
+	inverse bool
+	fx      bool
+}
+
+func (l crossLeg) from() string {
+	if l.inverse {
+		return string(l.pair.Currency)
+	}
+
+	return string(l.pair.Crypto)
+}
+
+func (l crossLeg) to() string {
+	if l.inverse {
+		return string(l.pair.Crypto)
+	}
+
+	return string(l.pair.Currency)
+}
+
+// CrossRate represents a provider that quotes the pairs the wrapped provider
+// doesn't list, or fails to quote, by chaining the pairs it does list (ex.
+// XRP/MXN as XRP/USD times USD/MXN). The derived quotes have their Path set.
+type CrossRate struct {
+	provider     PriceProvider
+	maxHops      int
+	fx           *FX
+	fxCurrencies []domain.Currency
+}
+
+// CrossRateOption represents an option to configure the cross rate engine.
+type CrossRateOption func(*CrossRate)
+
+// WithMaxHops sets the number of pairs a synthetic price may be derived
+// through (3 by default).
+func WithMaxHops(maxHops int) CrossRateOption {
+	return func(c *CrossRate) {
+		c.maxHops = maxHops
+	}
+}
+
+// WithFX converts between the given fiat currencies (ours by default) with
+// the FX service, besides the pairs of the provider.
+func WithFX(fx *FX, currencies ...domain.Currency) CrossRateOption {
+	return func(c *CrossRate) {
+		c.fx = fx
+		c.fxCurrencies = currencies
+		if len(currencies) == 0 {
+			c.fxCurrencies = domain.Currencies
+		}
+	}
+}
+
+// NewCrossRate creates a provider that derives the prices the given provider
+// doesn't quote directly.
+func NewCrossRate(provider PriceProvider, opts ...CrossRateOption) *CrossRate {
+	c := &CrossRate{
+		provider: provider,
+		maxHops:  defaultMaxHops,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *CrossRate) Name() string {
+	return c.provider.Name()
+}
+
+// SupportedPairs returns the pairs of the provider, plus the pairs of our
+// cryptos and currencies that can be derived from them.
+func (c *CrossRate) SupportedPairs() []Pair {
+	pairs := c.provider.SupportedPairs()
+	seen := make(map[Pair]bool, len(pairs))
+	for _, pair := range pairs {
+		seen[pair] = true
+	}
+
+	for _, crypto := range domain.Cryptos {
+		for _, currency := range domain.Currencies {
+			pair := Pair{Crypto: crypto, Currency: currency}
+			if !seen[pair] && len(c.paths(pair)) > 0 {
+				seen[pair] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	return pairs
+}
+
+// GetQuote returns the quote of the provider, or derives it through the
+// shortest conversion paths if the provider doesn't quote the pair.
+func (c *CrossRate) GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	target := Pair{Crypto: crypto, Currency: currency}
+
+	var errs []error
+	if supportsPair(c.provider, crypto, currency) {
+		quote, err := c.provider.GetQuote(ctx, crypto, currency)
+		if err == nil || ctx.Err() != nil {
+			return quote, err
+		}
+		errs = append(errs, err)
+	}
+
+	paths := c.paths(target)
+	if len(paths) == 0 && len(errs) == 0 {
+		return Quote{}, fmt.Errorf("%w: %s by %s", ErrUnsupportedPair, target, c.Name())
+	}
+
+	for _, path := range paths {
+		quote, err := c.derive(ctx, target, path)
+		if err == nil {
+			log.Printf("[%s][%s] derived %s through %s", crypto, currency, quote.Price, formatPath(quote.Path))
+			return quote, nil
+		}
+		if ctx.Err() != nil {
+			return Quote{}, ctx.Err()
+		}
+		errs = append(errs, err)
+	}
+
+	return Quote{}, fmt.Errorf("%w for %s: %w", ErrNoCrossRate, target, errors.Join(errs...))
+}
+
+// derive computes the price of the target pair through the path.
+func (c *CrossRate) derive(ctx context.Context, target Pair, path []crossLeg) (Quote, error) {
+	quote := Quote{
+		Pair:     target,
+		Price:    decimal.NewFromInt(1),
+		Provider: c.Name(),
+	}
+
+	var scale int32 = 2
+	for i, leg := range path {
+		legQuote, err := c.legQuote(ctx, leg)
+		if err != nil {
+			return Quote{}, fmt.Errorf("%s: %w", formatPath(pathPairs(path)), err)
+		}
+
+		rate := legQuote.Price
+		if leg.inverse {
+			if rate.IsZero() {
+				return Quote{}, fmt.Errorf("%s: zero %s price", formatPath(pathPairs(path)), leg.pair)
+			}
+			rate = decimal.NewFromInt(1).Div(rate, crossRateScale, decimal.RoundHalfEven)
+		} else if i == 0 {
+			// The derived price keeps the decimals of the crypto price.
+			scale = max(scale, rate.Scale())
+		}
+		quote.Price = quote.Price.Mul(rate)
+
+		// A derived price is as old as its oldest leg.
+		if quote.Time.IsZero() || legQuote.Time.Before(quote.Time) {
+			quote.Time = legQuote.Time
+		}
+	}
+
+	quote.Price = quote.Price.Round(scale, decimal.RoundHalfEven)
+	quote.Path = pathPairs(path)
+
+	return quote, nil
+}
+
+// legQuote returns the quote of the pair of the leg.
+func (c *CrossRate) legQuote(ctx context.Context, leg crossLeg) (Quote, error) {
+	if !leg.fx {
+		return c.provider.GetQuote(ctx, leg.pair.Crypto, leg.pair.Currency)
+	}
+
+	rate, err := c.fx.Rate(ctx, domain.Currency(leg.pair.Crypto), leg.pair.Currency)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	return Quote{Pair: leg.pair, Price: rate.Rate, Provider: rate.Source, Time: rate.Time}, nil
+}
+
+// paths returns the conversion paths from the crypto of the target to its
+// currency through the pairs of the provider, shortest first, leaving out
+// the direct pair.
+func (c *CrossRate) paths(target Pair) [][]crossLeg {
+	edges := make(map[string][]crossLeg)
+	for _, pair := range c.provider.SupportedPairs() {
+		edges[string(pair.Crypto)] = append(edges[string(pair.Crypto)], crossLeg{pair: pair})
+		edges[string(pair.Currency)] = append(edges[string(pair.Currency)], crossLeg{pair: pair, inverse: true})
+	}
+	if c.fx != nil {
+		for _, from := range c.fxCurrencies {
+			for _, to := range c.fxCurrencies {
+				if from != to {
+					edges[string(from)] = append(edges[string(from)], crossLeg{pair: Pair{Crypto: domain.CryptoCurrency(from), Currency: to}, fx: true})
+				}
+			}
+		}
+	}
+
+	var paths [][]crossLeg
+	visited := map[string]bool{string(target.Crypto): true}
+	var walk func(from string, path []crossLeg)
+	walk = func(from string, path []crossLeg) {
+		for _, leg := range edges[from] {
+			if leg.to() == string(target.Currency) {
+				if len(path) > 0 || leg.inverse {
+					paths = append(paths, append(append([]crossLeg(nil), path...), leg))
+				}
+				continue
+			}
+			if visited[leg.to()] || len(path)+2 > c.maxHops {
+				continue
+			}
+			visited[leg.to()] = true
+			walk(leg.to(), append(path, leg))
+			visited[leg.to()] = false
+		}
+	}
+	walk(string(target.Crypto), nil)
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return len(paths[i]) < len(paths[j])
+	})
+	if len(paths) > maxCrossPaths {
+		paths = paths[:maxCrossPaths]
+	}
+
+	return paths
+}
+
+// pathPairs returns the steps of a path as pairs, from the currency they are
+// converted from to the one they are converted to.
+func pathPairs(path []crossLeg) []Pair {
+	pairs := make([]Pair, len(path))
+	for i, leg := range path {
+		pairs[i] = Pair{Crypto: domain.CryptoCurrency(leg.from()), Currency: domain.Currency(leg.to())}
+	}
+
+	return pairs
+}
+
+// formatPath returns the path as a chain of currencies (ex. XRP→USD→MXN).
+func formatPath(path []Pair) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	steps := []string{string(path[0].Crypto)}
+	for _, pair := range path {
+		steps = append(steps, string(pair.Currency))
+	}
+
+	return strings.Join(steps, "→")
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+)
+
+func TestCrossRate(t *testing.T) {
+	xrpUsd := Pair{Crypto: domain.XRP, Currency: domain.USD}
+	usdMxn := Pair{Crypto: "USD", Currency: domain.MXN}
+	mxnUsd := Pair{Crypto: "MXN", Currency: domain.USD}
+	xrpMxn := Pair{Crypto: domain.XRP, Currency: domain.MXN}
+
+	tests := []struct {
+		name     string
+		prices   map[Pair]string
+		want     string
+		wantPath []Pair
+	}{
+		{
+			name:     "direct",
+			prices:   map[Pair]string{xrpMxn: "10.46", xrpUsd: "0.6286", usdMxn: "16.7877"},
+			want:     "10.46",
+			wantPath: nil,
+		},
+		{
+			name:     "through USD",
+			prices:   map[Pair]string{xrpUsd: "0.6286", usdMxn: "16.7877", btcUsd: "67187.34"},
+			want:     "10.5527",
+			wantPath: []Pair{xrpUsd, usdMxn},
+		},
+		{
+			name:     "through an inverse rate",
+			prices:   map[Pair]string{xrpUsd: "0.6286", mxnUsd: "0.05956"},
+			want:     "10.5541",
+			wantPath: []Pair{xrpUsd, usdMxn},
+		},
+		{
+			name: "through a stablecoin",
+			prices: map[Pair]string{
+				{Crypto: domain.XRP, Currency: "USDT"}: "0.6290",
+				{Crypto: "USDT", Currency: domain.MXN}: "16.80",
+			},
+			want:     "10.5672",
+			wantPath: []Pair{{Crypto: domain.XRP, Currency: "USDT"}, {Crypto: "USDT", Currency: domain.MXN}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crossRate := NewCrossRate(newStaticProvider("static", tt.prices, ""))
+
+			quote, err := crossRate.GetQuote(context.Background(), domain.XRP, domain.MXN)
+			if err != nil {
+				t.Fatalf("GetQuote() error = %v", err)
+			}
+			if quote.Price.String() != tt.want || quote.Pair != xrpMxn || quote.Provider != "static" {
+				t.Errorf("GetQuote() = %+v, want %s", quote, tt.want)
+			}
+			if !reflect.DeepEqual(quote.Path, tt.wantPath) || quote.Synthetic() != (tt.wantPath != nil) {
+				t.Errorf("GetQuote() path = %v, want %v", quote.Path, tt.wantPath)
+			}
+			if !supportsPair(crossRate, domain.XRP, domain.MXN) {
+				t.Errorf("SupportedPairs() = %v, want XRP/MXN", crossRate.SupportedPairs())
+			}
+		})
+	}
+}
+
+func TestCrossRateErrors(t *testing.T) {
+	xrpUsd := Pair{Crypto: domain.XRP, Currency: domain.USD}
+
+	crossRate := NewCrossRate(newStaticProvider("static", map[Pair]string{xrpUsd: "0.6286"}, ""))
+	if _, err := crossRate.GetQuote(context.Background(), domain.XRP, domain.MXN); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("GetQuote() without a path error = %v, want %v", err, ErrUnsupportedPair)
+	}
+
+	// Paths longer than the max hops are not taken.
+	provider := newStaticProvider("static", map[Pair]string{
+		xrpUsd:                                "0.6286",
+		{Crypto: "USD", Currency: "EUR"}:      "0.92",
+		{Crypto: "EUR", Currency: domain.MXN}: "18.25",
+	}, "")
+	if _, err := NewCrossRate(provider, WithMaxHops(2)).GetQuote(context.Background(), domain.XRP, domain.MXN); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("GetQuote() with 2 max hops error = %v, want %v", err, ErrUnsupportedPair)
+	}
+
+	provider.err = errors.New("unavailable")
+	if _, err := NewCrossRate(provider).GetQuote(context.Background(), domain.XRP, domain.MXN); !errors.Is(err, ErrNoCrossRate) {
+		t.Errorf("GetQuote() with a failing leg error = %v, want %v", err, ErrNoCrossRate)
+	}
+}
@@ -1,61 +1,140 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"log"
 	"math/rand"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
-	"github.com/umarquez/cryptocoins-go-challenge/sdk/bitso_client"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
 )
 
 // Crypto represents the service for the crypto domain.
 type Crypto interface {
-	GetValue(crypto domain.CryptoCurrency, currency domain.Currency) (string, error)
+	// GetValue returns the price of the crypto in the currency, with the
+	// conversion path it was derived through if it's synthetic.
+	GetValue(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (domain.Value, error)
 }
 
-type cacheItem struct {
-	value      string
-	expiration time.Time
-}
+// fxCheckWindow is the time a quote fetched for a request may be checked
+// against the quotes of the same crypto fetched for that request, which are
+// fetched concurrently and in any order.
+const fxCheckWindow = time.Minute
 
 type cryptoService struct {
-	cache map[string]cacheItem
+	provider    PriceProvider
+	fx          *FX
+	fxTolerance decimal.Decimal
+
+	fxMutex   sync.Mutex
+	fxUsd     map[domain.CryptoCurrency]fetchedQuote // fxUsd keeps the last USD quote of every crypto.
+	fxPending map[Pair]fetchedQuote                  // fxPending keeps the quotes waiting for a USD one to be checked against.
+}
+
+// fetchedQuote represents a quote and the time it was fetched.
+type fetchedQuote struct {
+	quote Quote
+	at    time.Time
 }
 
-var cryptoServiceInstance *cryptoService
+// CryptoServiceOption represents an option to configure the crypto service.
+type CryptoServiceOption func(*cryptoService)
 
-// GetCryptoService returns a single instance of the crypto service.
-func GetCryptoService() Crypto {
-	if cryptoServiceInstance == nil {
-		cryptoServiceInstance = &cryptoService{
-			cache: make(map[string]cacheItem),
-		}
+// WithFXCheck checks the prices quoted in the other currencies against the
+// USD ones with the FX rates, and logs the ones that imply a rate deviating
+// more than the tolerance (ex. 0.02 for 2%) from the market one.
+func WithFXCheck(fx *FX, tolerance decimal.Decimal) CryptoServiceOption {
+	return func(s *cryptoService) {
+		s.fx = fx
+		s.fxTolerance = tolerance
 	}
+}
 
-	return cryptoServiceInstance
+// NewCryptoService creates a crypto service that fetches the values with the
+// given provider (ex. one created by name with Providers.New). The values the
+// provider doesn't quote are derived through cross rates.
+func NewCryptoService(provider PriceProvider, opts ...CryptoServiceOption) Crypto {
+	return newCryptoService(provider, opts...)
 }
 
-func (s *cryptoService) GetValue(crypto domain.CryptoCurrency, currency domain.Currency) (string, error) {
+func newCryptoService(provider PriceProvider, opts ...CryptoServiceOption) *cryptoService {
+	if _, ok := provider.(*CrossRate); !ok {
+		provider = NewCrossRate(provider)
+	}
+
+	s := &cryptoService{
+		provider:  provider,
+		fxUsd:     make(map[domain.CryptoCurrency]fetchedQuote),
+		fxPending: make(map[Pair]fetchedQuote),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *cryptoService) GetValue(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (domain.Value, error) {
 	// Simulate a delay to simulate the time it takes to fetch the data.
 	delay := time.Duration(rand.Intn(4500)+500) * time.Millisecond // from 0.5 to 5 seconds
-	time.Sleep(delay)
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return domain.Value{}, ctx.Err()
+	}
 
-	// Fetch the value from the API.
-	c := bitso_client.NewClient(false)
+	quote, err := s.provider.GetQuote(ctx, crypto, currency)
+	if err != nil {
+		return domain.Value{}, err
+	}
+	if s.fx != nil {
+		s.checkFX(ctx, quote)
+	}
 
-	// Retry fetching the data up to 3 times in case of an error.
-	for retriesCount := 0; retriesCount < 3; retriesCount++ {
-		ticker, err := c.GetTicker(bitso_client.TickerName(fmt.Sprintf("%s_%s", strings.ToLower(string(crypto)), strings.ToLower(string(currency)))))
-		if err != nil {
-			fmt.Printf("(%v) Error fetching crypto_service value: %v\n", retriesCount, err)
-			continue
-		}
+	return domain.Value{Price: quote.Price.String(), Path: formatPath(quote.Path)}, nil
+}
 
-		return ticker.Payload.Last, nil
+// checkFX logs a mismatch between the quote and the USD price of its crypto.
+// No quote is fetched for the check: the quote is checked against the USD one
+// fetched within fxCheckWindow, or kept until the USD one is fetched.
+// The synthetic quotes are left out, as they are derived from the same rates.
+func (s *cryptoService) checkFX(ctx context.Context, quote Quote) {
+	if quote.Synthetic() {
+		return
 	}
 
-	return "", errors.New("failed to fetch %v value after 3 retries")
+	fetched := fetchedQuote{quote: quote, at: time.Now()}
+	var checks []Quote
+	s.fxMutex.Lock()
+	usd, hasUsd := s.fxUsd[quote.Crypto]
+	switch {
+	case quote.Currency == domain.USD:
+		usd = fetched
+		s.fxUsd[quote.Crypto] = fetched
+		for pair, pending := range s.fxPending {
+			if pair.Crypto != quote.Crypto {
+				continue
+			}
+			delete(s.fxPending, pair)
+			if fetched.at.Sub(pending.at) <= fxCheckWindow {
+				checks = append(checks, pending.quote)
+			}
+		}
+	case hasUsd && fetched.at.Sub(usd.at) <= fxCheckWindow:
+		checks = append(checks, quote)
+	default:
+		s.fxPending[quote.Pair] = fetched
+	}
+	s.fxMutex.Unlock()
+
+	for _, check := range checks {
+		if _, err := s.fx.Check(ctx, usd.quote, check, s.fxTolerance); errors.Is(err, ErrFXMismatch) {
+			log.Printf("[%s][%s] %v", check.Crypto, check.Currency, err)
+		} else if err != nil {
+			log.Printf("[%s][%s] fx.Check: %v", check.Crypto, check.Currency, err)
+		}
+	}
 }
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+)
+
+// FailoverProviderName is the name the failover chain is registered with.
+const FailoverProviderName = "failover"
+
+const (
+	// defaultFailoverProviders are the providers of the chain, in order, when
+	// none are configured. They don't need an API key.
+	defaultFailoverProviders = "bitso,coingecko,coinbase"
+	// defaultFailureThreshold is the default number of consecutive failures
+	// that open the circuit of a provider.
+	defaultFailureThreshold = 3
+	// defaultCoolDown is the default time an open circuit skips its provider.
+	defaultCoolDown = 30 * time.Second
+)
+
+// ErrAllProvidersFailed represents an error when no provider of the chain
+// could quote a pair.
+var ErrAllProvidersFailed = errors.New("all providers failed")
+
+func init() {
+	Providers.Register(FailoverProviderName, func() (PriceProvider, error) {
+		var opts []FailoverOption
+		if threshold := os.Getenv("FAILOVER_FAILURE_THRESHOLD"); threshold != "" {
+			n, err := strconv.Atoi(threshold)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FAILOVER_FAILURE_THRESHOLD: %w", err)
+			}
+			opts = append(opts, WithFailureThreshold(n))
+		}
+		if coolDown := os.Getenv("FAILOVER_COOL_DOWN"); coolDown != "" {
+			d, err := time.ParseDuration(coolDown)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FAILOVER_COOL_DOWN: %w", err)
+			}
+			opts = append(opts, WithCoolDown(d))
+		}
+
+		names := os.Getenv("FAILOVER_PROVIDERS")
+		if names == "" {
+			names = defaultFailoverProviders
+		}
+		var providers []PriceProvider
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || name == FailoverProviderName {
+				continue
+			}
+			provider, err := Providers.New(name)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		}
+
+		failover, err := NewFailover(providers, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return failover, nil
+	})
+}
+
+// ProviderState represents the state of a provider of the failover chain.
+type ProviderState struct {
+	Name          string       `json:"name"`
+	Active        bool         `json:"active"` // Active is true for the provider the requests are sent to first.
+	Circuit       CircuitState `json:"circuit"`
+	Failures      int          `json:"failures"`
+	OpenedAt      *time.Time   `json:"opened_at,omitempty"`
+	RetryAt       *time.Time   `json:"retry_at,omitempty"`
+	LastError     string       `json:"last_error,omitempty"`
+	LastSuccessAt *time.Time   `json:"last_success_at,omitempty"`
+}
+
+type chainLink struct {
+	provider PriceProvider
+	breaker  *CircuitBreaker
+
+	mutex         sync.Mutex
+	lastError     error
+	lastSuccessAt time.Time
+}
+
+// Failover represents a provider that quotes the pairs with the first
+// provider of an ordered chain that succeeds. Every provider is behind a
+// circuit breaker, so the providers that keep failing are skipped until
+// their cool-down is over.
+type Failover struct {
+	links            []*chainLink
+	failureThreshold int
+	coolDown         time.Duration
+}
+
+// FailoverOption represents an option to configure the failover chain.
+type FailoverOption func(*Failover)
+
+// WithFailureThreshold sets the number of consecutive failures that open the
+// circuit of a provider (3 by default).
+func WithFailureThreshold(failureThreshold int) FailoverOption {
+	return func(f *Failover) {
+		f.failureThreshold = failureThreshold
+	}
+}
+
+// WithCoolDown sets the time an open circuit skips its provider before a
+// trial request is sent (30 seconds by default).
+func WithCoolDown(coolDown time.Duration) FailoverOption {
+	return func(f *Failover) {
+		f.coolDown = coolDown
+	}
+}
+
+// NewFailover creates a provider that quotes the pairs with the given
+// providers, in order.
+func NewFailover(providers []PriceProvider, opts ...FailoverOption) (*Failover, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("the failover chain needs at least one provider")
+	}
+
+	f := &Failover{
+		failureThreshold: defaultFailureThreshold,
+		coolDown:         defaultCoolDown,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	if f.failureThreshold < 1 {
+		return nil, fmt.Errorf("invalid failure threshold %d", f.failureThreshold)
+	}
+	if f.coolDown < 0 {
+		return nil, fmt.Errorf("invalid cool-down %s", f.coolDown)
+	}
+
+	for _, provider := range providers {
+		f.links = append(f.links, &chainLink{
+			provider: provider,
+			breaker:  NewCircuitBreaker(f.failureThreshold, f.coolDown),
+		})
+	}
+
+	return f, nil
+}
+
+func (f *Failover) Name() string {
+	return FailoverProviderName
+}
+
+// SupportedPairs returns the pairs quoted by any of the providers.
+func (f *Failover) SupportedPairs() []Pair {
+	var pairs []Pair
+	seen := make(map[Pair]bool)
+	for _, link := range f.links {
+		for _, pair := range link.provider.SupportedPairs() {
+			if !seen[pair] {
+				seen[pair] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	return pairs
+}
+
+// GetQuote returns the quote of the first provider of the chain that quotes
+// the pair, skipping the providers whose circuit is open.
+func (f *Failover) GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	var errs []error
+	var supported bool
+	for _, link := range f.links {
+		if !supportsPair(link.provider, crypto, currency) {
+			continue
+		}
+		supported = true
+
+		name := link.provider.Name()
+		if err := link.breaker.Allow(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		quote, err := link.provider.GetQuote(ctx, crypto, currency)
+		if err == nil {
+			link.success()
+			return quote, nil
+		}
+
+		// The caller is gone, the provider didn't fail.
+		if ctx.Err() != nil {
+			link.breaker.Release()
+			return Quote{}, ctx.Err()
+		}
+
+		link.failure(err)
+		log.Printf("[%s][%s] %s failed, failing over: %v", crypto, currency, name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	if !supported {
+		return Quote{}, fmt.Errorf("%w: %s/%s by %s", ErrUnsupportedPair, crypto, currency, FailoverProviderName)
+	}
+
+	return Quote{}, fmt.Errorf("%w to quote %s/%s: %w", ErrAllProvidersFailed, crypto, currency, errors.Join(errs...))
+}
+
+// State returns the state of every provider of the chain, in order.
+func (f *Failover) State() []ProviderState {
+	states := make([]ProviderState, 0, len(f.links))
+	var active bool
+	for _, link := range f.links {
+		circuit := link.breaker.State()
+		state := ProviderState{
+			Name:     link.provider.Name(),
+			Circuit:  circuit.State,
+			Failures: circuit.Failures,
+		}
+		if !active && circuit.State != CircuitOpen {
+			state.Active, active = true, true
+		}
+		if !circuit.OpenedAt.IsZero() {
+			state.OpenedAt, state.RetryAt = &circuit.OpenedAt, &circuit.RetryAt
+		}
+
+		link.mutex.Lock()
+		if link.lastError != nil {
+			state.LastError = link.lastError.Error()
+		}
+		if !link.lastSuccessAt.IsZero() {
+			lastSuccessAt := link.lastSuccessAt
+			state.LastSuccessAt = &lastSuccessAt
+		}
+		link.mutex.Unlock()
+
+		states = append(states, state)
+	}
+
+	return states
+}
+
+func (l *chainLink) success() {
+	l.breaker.Success()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lastSuccessAt = time.Now()
+}
+
+// failure reports the provider failed. The pairs the provider doesn't quote
+// don't count as failures.
+func (l *chainLink) failure(err error) {
+	if errors.Is(err, ErrUnsupportedPair) {
+		l.breaker.Release()
+		return
+	}
+	l.breaker.Failure()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lastError = err
+}
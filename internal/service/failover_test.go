@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+)
+
+func TestFailover(t *testing.T) {
+	primary := newStaticProvider("primary", map[Pair]string{btcUsd: "67100.00"}, "")
+	secondary := newStaticProvider("secondary", map[Pair]string{btcUsd: "67200.00"}, "")
+	mxnOnly := newStaticProvider("mxn-only", map[Pair]string{{Crypto: domain.BTC, Currency: domain.MXN}: "1195432.10"}, "")
+
+	failover, err := NewFailover([]PriceProvider{primary, secondary, mxnOnly}, WithFailureThreshold(2), WithCoolDown(time.Minute))
+	if err != nil {
+		t.Fatalf("NewFailover() error = %v", err)
+	}
+
+	ctx := context.Background()
+	quote, err := failover.GetQuote(ctx, domain.BTC, domain.USD)
+	if err != nil || quote.Provider != "primary" {
+		t.Fatalf("GetQuote() = %+v, %v, want the primary quote", quote, err)
+	}
+
+	// The primary fails over to the secondary until its circuit opens, then
+	// it is skipped.
+	primary.err = errors.New("primary is down")
+	for i := 0; i < 3; i++ {
+		if quote, err = failover.GetQuote(ctx, domain.BTC, domain.USD); err != nil || quote.Provider != "secondary" {
+			t.Fatalf("GetQuote() #%d = %+v, %v, want the secondary quote", i, quote, err)
+		}
+	}
+
+	states := failover.State()
+	if len(states) != 3 || states[0].Circuit != CircuitOpen || states[0].Failures != 2 || states[0].LastError != "primary is down" || states[0].Active {
+		t.Errorf("State() primary = %+v", states[0])
+	}
+	if !states[1].Active || states[1].LastSuccessAt == nil {
+		t.Errorf("State() secondary = %+v, want active", states[1])
+	}
+	if states[2].Active || states[2].Circuit != CircuitClosed {
+		t.Errorf("State() mxn-only = %+v, want closed and inactive", states[2])
+	}
+
+	data, err := json.Marshal(states[0])
+	if err != nil || !strings.Contains(string(data), `"circuit":"open"`) || !strings.Contains(string(data), `"retry_at"`) {
+		t.Errorf("json.Marshal(State()) = %s, %v", data, err)
+	}
+
+	secondary.err = errors.New("secondary is down")
+	if _, err = failover.GetQuote(ctx, domain.BTC, domain.USD); !errors.Is(err, ErrAllProvidersFailed) || !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetQuote() error = %v, want %v", err, ErrAllProvidersFailed)
+	}
+
+	if _, err = failover.GetQuote(ctx, domain.ETH, domain.USD); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("GetQuote(ETH/USD) error = %v, want %v", err, ErrUnsupportedPair)
+	}
+
+	if _, err = NewFailover(nil); err == nil {
+		t.Error("NewFailover() without providers expected an error")
+	}
+}
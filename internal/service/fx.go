@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+const (
+	// defaultFXCacheTTL is the default time the fetched FX rates are reused.
+	defaultFXCacheTTL = 5 * time.Minute
+	// defaultFXHistorySize is the default number of rates kept by pair.
+	defaultFXHistorySize = 100
+	// fxRateScale is the number of decimals the inverse FX rates are computed
+	// with.
+	fxRateScale = 10
+)
+
+var (
+	// ErrUnsupportedCurrency represents an error when an FX provider doesn't
+	// quote a currency.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrFXMismatch represents an error when the prices of a crypto in two
+	// currencies imply an FX rate too far from the market one.
+	ErrFXMismatch = errors.New("prices don't match the FX rate")
+)
+
+// FXRate represents the price of a fiat currency (Base) in another one
+// (Quote), ex. 16.7877 MXN for 1 USD.
+type FXRate struct {
+	Base   domain.Currency
+	Quote  domain.Currency
+	Rate   decimal.Decimal
+	Source string    // Source is the name of the FX provider that gave the rate.
+	Time   time.Time // Time is when the rate was published by the provider.
+}
+
+// Invert returns the rate of the quote currency in the base one.
+func (r FXRate) Invert() (FXRate, error) {
+	if r.Rate.IsZero() {
+		return FXRate{}, fmt.Errorf("zero %s/%s rate", r.Base, r.Quote)
+	}
+
+	return FXRate{
+		Base:   r.Quote,
+		Quote:  r.Base,
+		Rate:   decimal.NewFromInt(1).Div(r.Rate, fxRateScale, decimal.RoundHalfEven),
+		Source: r.Source,
+		Time:   r.Time,
+	}, nil
+}
+
+// FXProvider represents a source of fiat exchange rates.
+type FXProvider interface {
+	// Name returns the name of the provider.
+	Name() string
+	// GetRate returns the current price of the base currency in the quote
+	// one, or an error wrapping ErrUnsupportedCurrency if the provider
+	// doesn't quote them.
+	GetRate(ctx context.Context, base, quote domain.Currency) (FXRate, error)
+}
+
+// StaticFXProvider represents an FX provider with fixed rates, for the tests
+// and the offline runs. The inverse of every rate is quoted too.
+// It is safe for concurrent use.
+type StaticFXProvider struct {
+	mutex sync.RWMutex
+	rates map[[2]domain.Currency]decimal.Decimal
+}
+
+// NewStaticFXProvider creates an FX provider with the given rates.
+func NewStaticFXProvider(rates ...FXRate) *StaticFXProvider {
+	p := &StaticFXProvider{rates: make(map[[2]domain.Currency]decimal.Decimal)}
+	for _, rate := range rates {
+		p.SetRate(rate.Base, rate.Quote, rate.Rate)
+	}
+
+	return p
+}
+
+// SetRate sets the price of the base currency in the quote one.
+func (p *StaticFXProvider) SetRate(base, quote domain.Currency, rate decimal.Decimal) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.rates[[2]domain.Currency{base, quote}] = rate
+	delete(p.rates, [2]domain.Currency{quote, base})
+}
+
+func (p *StaticFXProvider) Name() string {
+	return "static"
+}
+
+func (p *StaticFXProvider) GetRate(_ context.Context, base, quote domain.Currency) (FXRate, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if rate, ok := p.rates[[2]domain.Currency{base, quote}]; ok {
+		return FXRate{Base: base, Quote: quote, Rate: rate, Source: p.Name(), Time: time.Now().UTC()}, nil
+	}
+	if rate, ok := p.rates[[2]domain.Currency{quote, base}]; ok {
+		return FXRate{Base: quote, Quote: base, Rate: rate, Source: p.Name(), Time: time.Now().UTC()}.Invert()
+	}
+
+	return FXRate{}, fmt.Errorf("%w: %s/%s by %s", ErrUnsupportedCurrency, base, quote, p.Name())
+}
+
+type fxCacheItem struct {
+	rate       FXRate
+	expiration time.Time
+}
+
+// fxCall represents a fetch of a rate in flight.
+type fxCall struct {
+	done chan struct{} // done is closed once rate and err are set.
+	rate FXRate
+	err  error
+}
+
+// FX represents the fiat exchange rates service: it caches the rates of an FX
+// provider, keeps their history, and converts the crypto quotes between fiat
+// currencies. It is safe for concurrent use.
+type FX struct {
+	provider    FXProvider
+	ttl         time.Duration
+	historySize int
+
+	mutex   sync.Mutex
+	cache   map[[2]domain.Currency]fxCacheItem
+	calls   map[[2]domain.Currency]*fxCall
+	history map[[2]domain.Currency][]FXRate
+}
+
+// FXOption represents an option to configure the FX service.
+type FXOption func(*FX)
+
+// WithFXCacheTTL sets the time the fetched rates are reused (5 minutes by
+// default).
+func WithFXCacheTTL(ttl time.Duration) FXOption {
+	return func(fx *FX) {
+		fx.ttl = ttl
+	}
+}
+
+// WithFXHistorySize sets the number of rates kept by pair (100 by default).
+func WithFXHistorySize(size int) FXOption {
+	return func(fx *FX) {
+		fx.historySize = size
+	}
+}
+
+// NewFX creates an FX service with the rates of the given provider.
+func NewFX(provider FXProvider, opts ...FXOption) *FX {
+	fx := &FX{
+		provider:    provider,
+		ttl:         defaultFXCacheTTL,
+		historySize: defaultFXHistorySize,
+		cache:       make(map[[2]domain.Currency]fxCacheItem),
+		calls:       make(map[[2]domain.Currency]*fxCall),
+		history:     make(map[[2]domain.Currency][]FXRate),
+	}
+	for _, opt := range opts {
+		opt(fx)
+	}
+
+	return fx
+}
+
+// Rate returns the price of the base currency in the quote one, fetching it
+// from the provider if the cached rate expired.
+func (fx *FX) Rate(ctx context.Context, base, quote domain.Currency) (FXRate, error) {
+	if base == quote {
+		return FXRate{Base: base, Quote: quote, Rate: decimal.NewFromInt(1), Source: fx.provider.Name(), Time: time.Now().UTC()}, nil
+	}
+
+	key := [2]domain.Currency{base, quote}
+
+	fx.mutex.Lock()
+	if item, ok := fx.cache[key]; ok && time.Now().Before(item.expiration) {
+		fx.mutex.Unlock()
+		return item.rate, nil
+	}
+
+	// The concurrent misses of a pair wait for a single fetch. The lock isn't
+	// held while fetching, so a slow provider doesn't block the other pairs.
+	call, ok := fx.calls[key]
+	if !ok {
+		call = &fxCall{done: make(chan struct{})}
+		fx.calls[key] = call
+		go fx.fetch(context.WithoutCancel(ctx), key, call)
+	}
+	fx.mutex.Unlock()
+
+	select {
+	case <-call.done:
+		return call.rate, call.err
+	case <-ctx.Done():
+		return FXRate{}, ctx.Err()
+	}
+}
+
+// fetch gets the rate of the pair from the provider for the waiting calls,
+// and caches it.
+func (fx *FX) fetch(ctx context.Context, key [2]domain.Currency, call *fxCall) {
+	call.rate, call.err = fx.provider.GetRate(ctx, key[0], key[1])
+
+	fx.mutex.Lock()
+	defer fx.mutex.Unlock()
+	defer close(call.done)
+
+	delete(fx.calls, key)
+	if call.err != nil {
+		return
+	}
+
+	fx.cache[key] = fxCacheItem{rate: call.rate, expiration: time.Now().Add(fx.ttl)}
+	history := append(fx.history[key], call.rate)
+	if len(history) > fx.historySize {
+		history = history[len(history)-fx.historySize:]
+	}
+	fx.history[key] = history
+}
+
+// History returns the rates of the base currency in the quote one fetched
+// from the provider, oldest first.
+func (fx *FX) History(base, quote domain.Currency) []FXRate {
+	fx.mutex.Lock()
+	defer fx.mutex.Unlock()
+
+	return append([]FXRate(nil), fx.history[[2]domain.Currency{base, quote}]...)
+}
+
+// Convert returns the quote converted to the given currency. The converted
+// quote is synthetic, its path ends with the FX conversion.
+func (fx *FX) Convert(ctx context.Context, quote Quote, currency domain.Currency) (Quote, error) {
+	if quote.Currency == currency {
+		return quote, nil
+	}
+
+	rate, err := fx.Rate(ctx, quote.Currency, currency)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	path := quote.Path
+	if len(path) == 0 {
+		path = []Pair{quote.Pair}
+	}
+
+	converted := quote
+	converted.Currency = currency
+	converted.Price = quote.Price.Mul(rate.Rate).Round(max(quote.Price.Scale(), 2), decimal.RoundHalfEven)
+	converted.Volume = quote.Volume.Mul(rate.Rate).Round(2, decimal.RoundHalfEven)
+	converted.Path = append(append([]Pair(nil), path...), Pair{Crypto: domain.CryptoCurrency(rate.Base), Currency: rate.Quote})
+	// A converted price is as old as the older of the quote and the rate.
+	if rate.Time.Before(converted.Time) {
+		converted.Time = rate.Time
+	}
+
+	return converted, nil
+}
+
+// FXCheck represents the comparison of the FX rate implied by the prices of a
+// crypto in two currencies with the market rate.
+type FXCheck struct {
+	Implied   decimal.Decimal // Implied is the rate the prices imply.
+	Market    FXRate          // Market is the rate of the FX provider.
+	Deviation decimal.Decimal // Deviation is the difference of the rates, as a fraction of the market rate.
+}
+
+// Check compares the FX rate implied by the prices of the same crypto in two
+// currencies (ex. BTC/USD and BTC/MXN) with the market rate. It returns an
+// error wrapping ErrFXMismatch if they deviate more than the tolerance (ex.
+// 0.01 for 1%).
+func (fx *FX) Check(ctx context.Context, base, quote Quote, tolerance decimal.Decimal) (FXCheck, error) {
+	if base.Crypto != quote.Crypto {
+		return FXCheck{}, fmt.Errorf("can't compare %s and %s prices", base.Crypto, quote.Crypto)
+	}
+	if base.Price.IsZero() {
+		return FXCheck{}, fmt.Errorf("zero %s price", base.Pair)
+	}
+
+	market, err := fx.Rate(ctx, base.Currency, quote.Currency)
+	if err != nil {
+		return FXCheck{}, err
+	}
+	if market.Rate.IsZero() {
+		return FXCheck{}, fmt.Errorf("zero %s/%s rate", market.Base, market.Quote)
+	}
+
+	check := FXCheck{
+		Implied: quote.Price.Div(base.Price, fxRateScale, decimal.RoundHalfEven),
+		Market:  market,
+	}
+	check.Deviation = check.Implied.Sub(market.Rate).Abs().Div(market.Rate, fxRateScale, decimal.RoundHalfEven)
+
+	if check.Deviation.Cmp(tolerance) > 0 {
+		return check, fmt.Errorf("%w: %s and %s imply %s %s/%s, the market rate is %s",
+			ErrFXMismatch, base.Pair, quote.Pair, check.Implied.Round(4, decimal.RoundHalfEven), market.Base, market.Quote, market.Rate)
+	}
+
+	return check, nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// countingFXProvider counts the rates fetched from the wrapped provider.
+type countingFXProvider struct {
+	FXProvider
+	count int
+}
+
+func (p *countingFXProvider) GetRate(ctx context.Context, base, quote domain.Currency) (FXRate, error) {
+	p.count++
+	return p.FXProvider.GetRate(ctx, base, quote)
+}
+
+func TestFX(t *testing.T) {
+	static := NewStaticFXProvider(FXRate{Base: domain.USD, Quote: domain.MXN, Rate: decimal.MustParse("16.7877")})
+	provider := &countingFXProvider{FXProvider: static}
+	fx := NewFX(provider, WithFXHistorySize(2), WithFXCacheTTL(0))
+
+	ctx := context.Background()
+	rate, err := fx.Rate(ctx, domain.USD, domain.MXN)
+	if err != nil || rate.Rate.String() != "16.7877" || rate.Source != "static" {
+		t.Fatalf("Rate(USD/MXN) = %+v, %v", rate, err)
+	}
+
+	// The inverse rates are derived from the static ones.
+	if rate, err = fx.Rate(ctx, domain.MXN, domain.USD); err != nil || rate.Rate.String() != "0.0595674214" {
+		t.Errorf("Rate(MXN/USD) = %+v, %v", rate, err)
+	}
+
+	if _, err = fx.Rate(ctx, domain.USD, "EUR"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Rate(USD/EUR) error = %v, want %v", err, ErrUnsupportedCurrency)
+	}
+
+	// The history keeps the last rates fetched.
+	static.SetRate(domain.USD, domain.MXN, decimal.MustParse("16.80"))
+	_, _ = fx.Rate(ctx, domain.USD, domain.MXN)
+	static.SetRate(domain.USD, domain.MXN, decimal.MustParse("16.95"))
+	_, _ = fx.Rate(ctx, domain.USD, domain.MXN)
+
+	history := fx.History(domain.USD, domain.MXN)
+	if len(history) != 2 || history[0].Rate.String() != "16.80" || history[1].Rate.String() != "16.95" {
+		t.Errorf("History(USD/MXN) = %+v", history)
+	}
+
+	// The rates are reused until they expire.
+	cached := NewFX(provider)
+	provider.count = 0
+	for i := 0; i < 3; i++ {
+		_, _ = cached.Rate(ctx, domain.USD, domain.MXN)
+	}
+	if provider.count != 1 {
+		t.Errorf("provider got %d requests, want 1", provider.count)
+	}
+}
+
+// blockingFXProvider holds the rates of the base currency until released.
+type blockingFXProvider struct {
+	FXProvider
+	base    domain.Currency
+	release chan struct{}
+}
+
+func (p *blockingFXProvider) GetRate(ctx context.Context, base, quote domain.Currency) (FXRate, error) {
+	if base == p.base {
+		<-p.release
+	}
+	return p.FXProvider.GetRate(ctx, base, quote)
+}
+
+func TestFXConcurrentFetches(t *testing.T) {
+	static := NewStaticFXProvider(
+		FXRate{Base: domain.USD, Quote: domain.MXN, Rate: decimal.MustParse("16.7877")},
+		FXRate{Base: "EUR", Quote: domain.MXN, Rate: decimal.MustParse("18.1923")},
+	)
+	counting := &countingFXProvider{FXProvider: static}
+	provider := &blockingFXProvider{FXProvider: counting, base: "EUR", release: make(chan struct{})}
+	fx := NewFX(provider)
+
+	ctx := context.Background()
+	if _, err := fx.Rate(ctx, domain.USD, domain.MXN); err != nil {
+		t.Fatalf("Rate(USD/MXN) error = %v", err)
+	}
+
+	results := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := fx.Rate(ctx, "EUR", domain.MXN)
+			results <- err
+		}()
+	}
+
+	// The cached pairs are served while another pair is being fetched.
+	done := make(chan struct{})
+	go func() {
+		_, _ = fx.Rate(ctx, domain.USD, domain.MXN)
+		_ = fx.History(domain.USD, domain.MXN)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Rate(USD/MXN) blocked by the EUR/MXN fetch")
+	}
+
+	// A caller that gives up doesn't wait for the fetch.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := fx.Rate(canceled, "EUR", domain.MXN); !errors.Is(err, context.Canceled) {
+		t.Errorf("Rate(EUR/MXN) error = %v, want %v", err, context.Canceled)
+	}
+
+	close(provider.release)
+	for i := 0; i < 3; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("Rate(EUR/MXN) error = %v", err)
+		}
+	}
+	// The concurrent misses shared a single fetch.
+	if counting.count != 2 {
+		t.Errorf("provider got %d requests, want 2", counting.count)
+	}
+}
+
+func TestFXConvertAndCheck(t *testing.T) {
+	fx := NewFX(NewStaticFXProvider(FXRate{Base: domain.USD, Quote: domain.MXN, Rate: decimal.MustParse("16.7877")}))
+	ctx := context.Background()
+
+	usd := Quote{Pair: btcUsd, Price: decimal.MustParse("67187.34"), Provider: "a", Time: time.Unix(1711356300, 0)}
+	mxn, err := fx.Convert(ctx, usd, domain.MXN)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	wantPath := []Pair{btcUsd, {Crypto: "USD", Currency: domain.MXN}}
+	if mxn.Price.String() != "1127920.91" || mxn.Currency != domain.MXN || !reflect.DeepEqual(mxn.Path, wantPath) || !mxn.Time.Equal(usd.Time) {
+		t.Errorf("Convert() = %+v", mxn)
+	}
+
+	tolerance := decimal.MustParse("0.01")
+	check, err := fx.Check(ctx, usd, mxn, tolerance)
+	if err != nil || check.Deviation.Cmp(decimal.MustParse("0.000001")) > 0 {
+		t.Errorf("Check() = %+v, %v", check, err)
+	}
+
+	// A stale MXN price implies a rate 5% off the market one.
+	mxn.Price = decimal.MustParse("1071524.86")
+	if _, err = fx.Check(ctx, usd, mxn, tolerance); !errors.Is(err, ErrFXMismatch) {
+		t.Errorf("Check() error = %v, want %v", err, ErrFXMismatch)
+	}
+}
+
+// countingProvider counts the quotes fetched from the wrapped provider.
+type countingProvider struct {
+	PriceProvider
+	count int
+}
+
+func (p *countingProvider) GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error) {
+	p.count++
+	return p.PriceProvider.GetQuote(ctx, crypto, currency)
+}
+
+func TestCryptoServiceFXCheck(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	fx := NewFX(NewStaticFXProvider(FXRate{Base: domain.USD, Quote: domain.MXN, Rate: decimal.MustParse("16.7877")}))
+	btcMxn := Pair{Crypto: domain.BTC, Currency: domain.MXN}
+	ethUsd, ethMxn := Pair{Crypto: domain.ETH, Currency: domain.USD}, Pair{Crypto: domain.ETH, Currency: domain.MXN}
+	xrpMxn := Pair{Crypto: domain.XRP, Currency: domain.MXN}
+
+	// The MXN prices are 5% off the USD ones. There is no XRP/USD price to
+	// check XRP/MXN against.
+	provider := &countingProvider{PriceProvider: newStaticProvider("static", map[Pair]string{
+		btcUsd: "67187.34", btcMxn: "1071524.86",
+		ethUsd: "3120.50", ethMxn: "49767.18",
+		xrpMxn: "10.02",
+	}, "")}
+	s := newCryptoService(provider, WithFXCheck(fx, decimal.MustParse("0.02")))
+
+	// The quotes are checked whatever the order they are fetched in.
+	for _, pair := range []Pair{btcUsd, btcMxn, ethMxn, ethUsd, xrpMxn} {
+		quote, err := s.provider.GetQuote(context.Background(), pair.Crypto, pair.Currency)
+		if err != nil {
+			t.Fatalf("GetQuote(%s) error = %v", pair, err)
+		}
+		count := provider.count
+		s.checkFX(context.Background(), quote)
+		if provider.count != count {
+			t.Errorf("checkFX(%s) fetched %d quotes, want 0", pair, provider.count-count)
+		}
+	}
+
+	if !strings.Contains(logs.String(), "[BTC][MXN] "+ErrFXMismatch.Error()) || !strings.Contains(logs.String(), "[ETH][MXN] "+ErrFXMismatch.Error()) ||
+		strings.Count(logs.String(), ErrFXMismatch.Error()) != 2 {
+		t.Errorf("logs = %q, want the BTC/MXN and ETH/MXN mismatches", logs.String())
+	}
+}
+
+func TestCrossRateWithFX(t *testing.T) {
+	xrpUsd := Pair{Crypto: domain.XRP, Currency: domain.USD}
+	fx := NewFX(NewStaticFXProvider(FXRate{Base: domain.USD, Quote: domain.MXN, Rate: decimal.MustParse("16.7877")}))
+
+	crossRate := NewCrossRate(newStaticProvider("static", map[Pair]string{xrpUsd: "0.6286"}, ""), WithFX(fx))
+	quote, err := crossRate.GetQuote(context.Background(), domain.XRP, domain.MXN)
+	if err != nil || quote.Price.String() != "10.5527" || !reflect.DeepEqual(quote.Path, []Pair{xrpUsd, {Crypto: "USD", Currency: domain.MXN}}) {
+		t.Errorf("GetQuote() = %+v, %v", quote, err)
+	}
+}
+
+func TestCoinbaseFXProvider(t *testing.T) {
+	date := time.Date(2024, 3, 25, 8, 45, 12, 0, time.UTC)
+	provider := NewCoinbaseFXProvider(WithCoinbaseBaseUrl(newCoinbaseServer(t, date).URL))
+
+	rate, err := provider.GetRate(context.Background(), domain.USD, domain.MXN)
+	if err != nil || rate.Rate.String() != "16.7877" || rate.Source != CoinbaseProviderName || !rate.Time.Equal(date) {
+		t.Errorf("GetRate(USD/MXN) = %+v, %v", rate, err)
+	}
+
+	if _, err = provider.GetRate(context.Background(), domain.USD, "XYZ"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("GetRate(USD/XYZ) error = %v, want %v", err, ErrUnsupportedCurrency)
+	}
+}
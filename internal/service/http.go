@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// maxErrorMessageLength is the length the body of an unsuccessful response is
+// cut to when it is used as an error message.
+const maxErrorMessageLength = 256
+
+var (
+	// ErrProviderUnauthorized represents an error when a provider rejects the
+	// API key, or the plan of the key doesn't allow the request.
+	ErrProviderUnauthorized = errors.New("provider unauthorized")
+	// ErrProviderRateLimited represents an error when a provider rejects the
+	// request because too many were sent recently.
+	ErrProviderRateLimited = errors.New("provider rate limit exceeded")
+	// ErrProviderQuotaExceeded represents an error when the credits of the API
+	// key are exhausted for the day or the month.
+	ErrProviderQuotaExceeded = errors.New("provider quota exceeded")
+	// ErrProviderUnavailable represents an error when a provider fails to
+	// serve the request.
+	ErrProviderUnavailable = errors.New("provider unavailable")
+)
+
+// ProviderError represents an unsuccessful response of a provider API.
+// It matches the Err sentinel with errors.Is.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Code       string // Code is the error code of the provider, if any.
+	Message    string
+	Err        error // Err is one of the ErrProvider errors, or nil if the request was rejected for another reason.
+}
+
+func (e *ProviderError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s API error %s (HTTP %d): %s", e.Provider, e.Code, e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("%s API error (HTTP %d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the request may succeed later: rate limits and
+// server errors are retryable, while rejected requests and exhausted quotas
+// are not.
+func (e *ProviderError) Retryable() bool {
+	return errors.Is(e.Err, ErrProviderRateLimited) || errors.Is(e.Err, ErrProviderUnavailable)
+}
+
+// statusError returns the ErrProvider error of an unsuccessful HTTP status.
+func statusError(statusCode int) error {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusPaymentRequired || statusCode == http.StatusForbidden:
+		return ErrProviderUnauthorized
+	case statusCode == http.StatusTooManyRequests:
+		return ErrProviderRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		return ErrProviderUnavailable
+	}
+
+	return nil
+}
+
+// retryable reports whether a failed provider request may succeed if it is
+// retried: unsuccessful responses tell it themselves, network failures are
+// retryable and invalid responses are not.
+func retryable(err error) bool {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Retryable()
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// errorDecoder fills the error of an unsuccessful response with the details
+// the provider sends in the body.
+type errorDecoder func(body []byte, err *ProviderError)
+
+// getJSON sends a GET request to the url with the given headers, decodes the
+// JSON response into out and returns the response headers. Unsuccessful
+// responses are returned as a *ProviderError, detailed by decodeError if it
+// is not nil.
+func getJSON(ctx context.Context, client *http.Client, provider, url string, header http.Header, out any, decodeError errorDecoder) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(string(body))
+		if len(message) > maxErrorMessageLength {
+			message = message[:maxErrorMessageLength]
+		}
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		providerErr := &ProviderError{Provider: provider, StatusCode: resp.StatusCode, Message: message, Err: statusError(resp.StatusCode)}
+		if decodeError != nil {
+			decodeError(body, providerErr)
+		}
+		return resp.Header, providerErr
+	}
+
+	if err = json.Unmarshal(body, out); err != nil {
+		return resp.Header, fmt.Errorf("failed to decode %s response: %w", provider, err)
+	}
+
+	return resp.Header, nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+var (
+	// ErrUnknownProvider represents an error when no provider is registered
+	// with the given name.
+	ErrUnknownProvider = errors.New("unknown price provider")
+	// ErrUnsupportedPair represents an error when a provider doesn't quote
+	// the given crypto in the given currency.
+	ErrUnsupportedPair = errors.New("unsupported pair")
+)
+
+// Pair represents a crypto quoted in a currency (ex. BTC/MXN).
+type Pair struct {
+	Crypto   domain.CryptoCurrency
+	Currency domain.Currency
+}
+
+func (p Pair) String() string {
+	return fmt.Sprintf("%s/%s", p.Crypto, p.Currency)
+}
+
+// Quote represents the price of a crypto in a currency given by a provider.
+type Quote struct {
+	Pair
+	Price    decimal.Decimal
+	Volume   decimal.Decimal // Volume is the traded volume of the last 24 hours in the currency, or zero if the provider doesn't report it.
+	Provider string          // Provider is the name of the provider that gave the price.
+	Time     time.Time       // Time is when the price was published by the provider.
+	Sources  []string        // Sources are the providers an aggregated price was computed from.
+	Path     []Pair          // Path are the conversions a synthetic price was derived through (ex. XRP/USD, USD/MXN).
+}
+
+// Synthetic reports whether the price was derived through other pairs rather
+// than quoted directly.
+func (q Quote) Synthetic() bool {
+	return len(q.Path) > 0
+}
+
+// PriceProvider represents a source of crypto prices (ex. an exchange or an
+// aggregator).
+type PriceProvider interface {
+	// Name returns the name the provider is registered with.
+	Name() string
+	// SupportedPairs returns the pairs the provider can quote.
+	SupportedPairs() []Pair
+	// GetQuote returns the current price of the crypto in the currency, or
+	// an error wrapping ErrUnsupportedPair if the provider doesn't quote it.
+	GetQuote(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (Quote, error)
+}
+
+// ProviderFactory creates a price provider, reading its configuration
+// (ex. API keys) from the environment.
+type ProviderFactory func() (PriceProvider, error)
+
+// ProviderRegistry represents the price providers available by name.
+// All the methods are safe for concurrent use.
+type ProviderRegistry struct {
+	mutex     sync.RWMutex
+	factories map[string]ProviderFactory
+}
+
+// NewProviderRegistry creates an empty registry.
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{factories: make(map[string]ProviderFactory)}
+}
+
+// Register adds a provider to the registry, replacing any provider
+// registered with the same name.
+func (r *ProviderRegistry) Register(name string, factory ProviderFactory) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.factories[name] = factory
+}
+
+// New creates the provider registered with the given name.
+func (r *ProviderRegistry) New(name string) (PriceProvider, error) {
+	r.mutex.RLock()
+	factory, ok := r.factories[name]
+	r.mutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (available: %v)", ErrUnknownProvider, name, r.Names())
+	}
+
+	provider, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", name, err)
+	}
+
+	return provider, nil
+}
+
+// Names returns the names of the registered providers, sorted.
+func (r *ProviderRegistry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Providers is the registry the built-in providers register themselves in.
+var Providers = NewProviderRegistry()
+
+// supportsPair reports whether the provider quotes the given pair.
+func supportsPair(provider PriceProvider, crypto domain.CryptoCurrency, currency domain.Currency) bool {
+	for _, p := range provider.SupportedPairs() {
+		if p.Crypto == crypto && p.Currency == currency {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quoteCache keeps the last quotes fetched from a provider for a while, so
+// the concurrent lookups share a single request.
+type quoteCache struct {
+	mutex      sync.Mutex
+	ttl        time.Duration
+	quotes     map[Pair]Quote
+	expiration time.Time
+	call       *quoteCall // call is the fetch in flight, if any.
+}
+
+// quoteCall represents a fetch of the quotes in flight.
+type quoteCall struct {
+	done   chan struct{} // done is closed once quotes and err are set.
+	quotes map[Pair]Quote
+	err    error
+}
+
+// get returns the cached quote of the pair, fetching the quotes of every pair
+// first if they expired. The concurrent lookups wait for a single fetch, which
+// runs without holding the lock, and read its results. A lookup whose context
+// is done stops waiting, without canceling the fetch for the others.
+func (c *quoteCache) get(ctx context.Context, pair Pair, fetch func(ctx context.Context) (map[Pair]Quote, error)) (Quote, bool, error) {
+	c.mutex.Lock()
+	if c.quotes != nil && time.Now().Before(c.expiration) {
+		quote, ok := c.quotes[pair]
+		c.mutex.Unlock()
+		return quote, ok, nil
+	}
+
+	call := c.call
+	if call == nil {
+		call = &quoteCall{done: make(chan struct{})}
+		c.call = call
+		go c.fetch(context.WithoutCancel(ctx), call, fetch)
+	}
+	c.mutex.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return Quote{}, false, ctx.Err()
+	}
+	if call.err != nil {
+		return Quote{}, false, call.err
+	}
+
+	quote, ok := call.quotes[pair]
+	return quote, ok, nil
+}
+
+// fetch gets the quotes for the waiting lookups, and caches them.
+func (c *quoteCache) fetch(ctx context.Context, call *quoteCall, fetch func(ctx context.Context) (map[Pair]Quote, error)) {
+	call.quotes, call.err = fetch(ctx)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	defer close(call.done)
+
+	c.call = nil
+	if call.err == nil {
+		c.quotes, c.expiration = call.quotes, time.Now().Add(c.ttl)
+	}
+}
+
+// fetchRetries is the number of times a provider request is attempted.
+const fetchRetries = 3
+
+// retryBackoff is the time waited before the first retry, doubled before
+// every next one.
+var retryBackoff = 250 * time.Millisecond
+
+// fetchWithRetries calls fetch until it succeeds, it fails with an error that
+// is not retryable, the context is done or it is attempted fetchRetries times.
+func fetchWithRetries(ctx context.Context, name string, retryable func(error) bool, fetch func(ctx context.Context) error) error {
+	var err error
+	backoff := retryBackoff
+	for retriesCount := 0; retriesCount < fetchRetries; retriesCount++ {
+		if retriesCount > 0 {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err = fetch(ctx); err == nil {
+			return nil
+		}
+		// Stop retrying once the caller is gone.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("[%s] attempt %d failed: %v", name, retriesCount+1, err)
+		// Invalid responses won't succeed on a retry.
+		if !retryable(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("failed to fetch %s quotes after %d retries: %w", name, fetchRetries, err)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/bitso_client"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/bitso_client/bitsotest"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+func TestProviderRegistry(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register("fake", func() (PriceProvider, error) {
+		return NewBitsoProvider(nil), nil
+	})
+	registry.Register("broken", func() (PriceProvider, error) {
+		return nil, errors.New("missing API key")
+	})
+
+	if got := registry.Names(); !reflect.DeepEqual(got, []string{"broken", "fake"}) {
+		t.Errorf("Names() = %v", got)
+	}
+
+	if provider, err := registry.New("fake"); err != nil || provider.Name() != BitsoProviderName {
+		t.Errorf("New(fake) = %v, %v", provider, err)
+	}
+
+	if _, err := registry.New("broken"); err == nil {
+		t.Error("New(broken) expected an error")
+	}
+
+	if _, err := registry.New("unknown"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("New(unknown) error = %v, want %v", err, ErrUnknownProvider)
+	}
+
+	// Bitso registers itself in the default registry.
+	if _, err := Providers.New(BitsoProviderName); err != nil {
+		t.Errorf("Providers.New(%s) error = %v", BitsoProviderName, err)
+	}
+}
+
+func TestBitsoProvider(t *testing.T) {
+	srv := bitsotest.NewServer()
+	defer srv.Close()
+
+	srv.SetTicker(bitso_client.BTC_MXN, bitsotest.Ticker{Last: decimal.MustParse("512345.67")})
+	srv.SetTicker(bitso_client.ETH_USD, bitsotest.Ticker{Last: decimal.MustParse("3120.5")})
+
+	provider := NewBitsoProvider(srv.Client())
+	if len(provider.SupportedPairs()) != len(domain.Cryptos)*len(domain.Currencies)+len(bitsoBridgePairs) {
+		t.Errorf("SupportedPairs() = %v", provider.SupportedPairs())
+	}
+
+	ctx := context.Background()
+	quote, err := provider.GetQuote(ctx, domain.BTC, domain.MXN)
+	if err != nil {
+		t.Fatalf("GetQuote() error = %v", err)
+	}
+	if quote.Price.String() != "512345.67" || quote.Crypto != domain.BTC || quote.Currency != domain.MXN || quote.Provider != BitsoProviderName {
+		t.Errorf("GetQuote() = %+v", quote)
+	}
+
+	// The second quote is served from the tickers fetched by the first one.
+	if quote, err = provider.GetQuote(ctx, domain.ETH, domain.USD); err != nil || quote.Price.String() != "3120.5" {
+		t.Errorf("GetQuote(ETH/USD) = %+v, %v", quote, err)
+	}
+	if count := srv.RequestCount("/ticker"); count != 1 {
+		t.Errorf("server got %d ticker requests, want 1", count)
+	}
+
+	if _, err = provider.GetQuote(ctx, domain.XRP, domain.MXN); !errors.Is(err, bitso_client.ErrUnknownBook) {
+		t.Errorf("GetQuote(XRP/MXN) error = %v, want %v", err, bitso_client.ErrUnknownBook)
+	}
+
+	if _, err = provider.GetQuote(ctx, domain.BTC, "EUR"); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("GetQuote(BTC/EUR) error = %v, want %v", err, ErrUnsupportedPair)
+	}
+}
+
+func TestQuoteCacheConcurrentLookups(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	cache := quoteCache{ttl: time.Minute}
+	fetch := func(ctx context.Context) (map[Pair]Quote, error) {
+		fetches.Add(1)
+		<-release
+		return map[Pair]Quote{btcUsd: {Pair: btcUsd, Price: decimal.MustParse("67187.34")}}, nil
+	}
+
+	results := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, _, err := cache.get(context.Background(), btcUsd, fetch)
+			results <- err
+		}()
+	}
+
+	// A lookup that gives up doesn't wait for the slow fetch.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := cache.get(ctx, btcUsd, fetch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("get() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("get() error = %v", err)
+		}
+	}
+	if quote, ok, err := cache.get(context.Background(), btcUsd, fetch); err != nil || !ok || quote.Price.String() != "67187.34" {
+		t.Errorf("get() = %+v, %v, %v", quote, ok, err)
+	}
+	// The concurrent lookups shared a single fetch.
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+}
@@ -1,8 +1,11 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,7 +16,7 @@ import (
 
 // CryptoService defines the contract for crypto_service business logic.
 type CryptoService interface {
-	GetValue(crypto domain.CryptoCurrency, currency domain.Currency) (string, error)
+	GetValue(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (domain.Value, error)
 }
 
 // CryptoRepo defines the contract for crypto_repo business logic.
@@ -24,8 +27,8 @@ type CryptoRepo interface {
 
 // CryptoUseCase defines the contract for crypto_service business logic.
 type CryptoUseCase interface {
-	GetAllCryptos() ([]domain.Crypto, error)
-	GetCryptoById(id int) (domain.Crypto, error)
+	GetAllCryptos(ctx context.Context) ([]domain.Crypto, error)
+	GetCryptoById(ctx context.Context, id int) (domain.Crypto, error)
 }
 
 type cryptoUseCase struct {
@@ -41,34 +44,44 @@ func NewCryptoUseCase(srv CryptoService, repo CryptoRepo) CryptoUseCase {
 }
 
 // getCryptoValueAsync retrieves the last price of a cryptocurrency in a given currency.
-func (uc *cryptoUseCase) getCryptoValueAsync(crypto domain.CryptoCurrency, currency domain.Currency, wg *sync.WaitGroup, results map[domain.CryptoCurrency]map[domain.Currency]string, resultsWriterMutex *sync.Mutex) {
+func (uc *cryptoUseCase) getCryptoValueAsync(ctx context.Context, crypto domain.CryptoCurrency, currency domain.Currency, wg *sync.WaitGroup, results map[domain.CryptoCurrency]map[domain.Currency]domain.Value, resultsWriterMutex *sync.Mutex) {
 	log.Printf("[%s][%s] thread started\n", crypto, currency)
 	startTime := time.Now()
 	defer wg.Done()
 
-	value, err := uc.cryptoRepo.GetValue(fmt.Sprintf("%s_%s", crypto, currency))
+	storedValue, err := uc.cryptoRepo.GetValue(fmt.Sprintf("%s_%s", crypto, currency))
 	if err != nil {
 		log.Printf("[%s][%s] cryptoRepo.GetValue: %v", crypto, currency, err)
 		return
 	}
 
-	if value == "" {
+	var value domain.Value
+	if storedValue == "" {
 		log.Printf("[%s][%s] cryptoRepo.GetValue returned an empty value", crypto, currency)
 		log.Printf("[%s][%s] fetching value from cryptoService", crypto, currency)
-		value, err = uc.cryptoService.GetValue(crypto, currency)
+		value, err = uc.cryptoService.GetValue(ctx, crypto, currency)
 		if err != nil {
 			log.Printf("[%s][%s] cryptoService.GetValue: %v", crypto, currency, err)
 			return
 		}
 
 		log.Printf("[%s][%s] storing value in cryptoRepo", crypto, currency)
-		err = uc.cryptoRepo.StoreValue(fmt.Sprintf("%s_%s", crypto, currency), value)
+		encodedValue, err := json.Marshal(value)
+		if err != nil {
+			log.Printf("[%s][%s] json.Marshal: %v", crypto, currency, err)
+			return
+		}
+		err = uc.cryptoRepo.StoreValue(fmt.Sprintf("%s_%s", crypto, currency), string(encodedValue))
 		if err != nil {
 			log.Printf("[%s][%s] cryptoRepo.StoreValue: %v", crypto, currency, err)
 			return
 		}
 	} else {
 		log.Printf("[%s][%s] value found in cache", crypto, currency)
+		if err := json.Unmarshal([]byte(storedValue), &value); err != nil {
+			// The values stored before the paths were kept are bare prices.
+			value = domain.Value{Price: storedValue}
+		}
 	}
 
 	log.Printf("[%s][%s] thread writing results", crypto, currency)
@@ -79,9 +92,9 @@ func (uc *cryptoUseCase) getCryptoValueAsync(crypto domain.CryptoCurrency, curre
 	log.Printf("[%s][%s] thread done after %v seconds", crypto, currency, t)
 }
 
-func (uc *cryptoUseCase) GetAllCryptos() ([]domain.Crypto, error) {
+func (uc *cryptoUseCase) GetAllCryptos(ctx context.Context) ([]domain.Crypto, error) {
 	// Create a map to store the results.
-	results := make(map[domain.CryptoCurrency]map[domain.Currency]string)
+	results := make(map[domain.CryptoCurrency]map[domain.Currency]domain.Value)
 	resultsWriterMutex := new(sync.Mutex)
 
 	// Create a wait group to wait for all goroutines to finish.
@@ -89,15 +102,18 @@ func (uc *cryptoUseCase) GetAllCryptos() ([]domain.Crypto, error) {
 	for _, crypto := range domain.Cryptos {
 		for _, currency := range domain.Currencies {
 			if _, ok := results[crypto]; !ok {
-				results[crypto] = make(map[domain.Currency]string)
+				results[crypto] = make(map[domain.Currency]domain.Value)
 			}
 
 			wg.Add(1)
-			go uc.getCryptoValueAsync(crypto, currency, wg, results, resultsWriterMutex)
+			go uc.getCryptoValueAsync(ctx, crypto, currency, wg, results, resultsWriterMutex)
 		}
 	}
 
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	fmt.Printf("Results: %v\n", results)
 
 	cryptoList := []domain.Crypto{}
@@ -106,10 +122,7 @@ func (uc *cryptoUseCase) GetAllCryptos() ([]domain.Crypto, error) {
 			Date:         time.Now(),
 			Name:         domain.CryptoName[simbol],
 			TickerSymbol: string(simbol),
-			Price: domain.Price{
-				USD: prices[domain.USD],
-				MXN: prices[domain.MXN],
-			},
+			Price:        newPrice(prices),
 		}
 		cryptoList = append(cryptoList, c)
 	}
@@ -118,9 +131,9 @@ func (uc *cryptoUseCase) GetAllCryptos() ([]domain.Crypto, error) {
 }
 
 // GetCryptoById returns a single instance of the crypto service.
-func (uc *cryptoUseCase) GetCryptoById(id int) (domain.Crypto, error) {
+func (uc *cryptoUseCase) GetCryptoById(ctx context.Context, id int) (domain.Crypto, error) {
 	// Create a map to store the results.
-	results := make(map[domain.CryptoCurrency]map[domain.Currency]string)
+	results := make(map[domain.CryptoCurrency]map[domain.Currency]domain.Value)
 	resultsWriterMutex := new(sync.Mutex)
 
 	// Create a wait group to wait for all goroutines to finish.
@@ -132,14 +145,17 @@ func (uc *cryptoUseCase) GetCryptoById(id int) (domain.Crypto, error) {
 
 	for _, currency := range domain.Currencies {
 		if _, ok := results[crypto]; !ok {
-			results[crypto] = make(map[domain.Currency]string)
+			results[crypto] = make(map[domain.Currency]domain.Value)
 		}
 
 		wg.Add(1)
-		go uc.getCryptoValueAsync(crypto, currency, wg, results, resultsWriterMutex)
+		go uc.getCryptoValueAsync(ctx, crypto, currency, wg, results, resultsWriterMutex)
 	}
 
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return domain.Crypto{}, err
+	}
 	fmt.Printf("Results: %v\n", results)
 
 	for simbol, prices := range results {
@@ -147,13 +163,30 @@ func (uc *cryptoUseCase) GetCryptoById(id int) (domain.Crypto, error) {
 			Date:         time.Now(),
 			Name:         domain.CryptoName[simbol],
 			TickerSymbol: string(simbol),
-			Price: domain.Price{
-				USD: prices[domain.USD],
-				MXN: prices[domain.MXN],
-			},
+			Price:        newPrice(prices),
 		}
 		return c, nil
 	}
 
 	return domain.Crypto{}, fmt.Errorf("crypto not found") // should never reach this point
 }
+
+// newPrice returns the pricing details of the values of a cryptocurrency,
+// annotating the synthetic ones with their conversion path.
+func newPrice(values map[domain.Currency]domain.Value) domain.Price {
+	price := domain.Price{
+		USD: values[domain.USD].Price,
+		MXN: values[domain.MXN].Price,
+	}
+	for currency, value := range values {
+		if !value.Synthetic() {
+			continue
+		}
+		if price.Synthetic == nil {
+			price.Synthetic = make(map[string]string)
+		}
+		price.Synthetic[strings.ToLower(string(currency))] = value.Path
+	}
+
+	return price
+}
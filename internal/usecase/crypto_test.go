@@ -1,14 +1,23 @@
 package usecase_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/umarquez/cryptocoins-go-challenge/internal/domain"
+	"github.com/umarquez/cryptocoins-go-challenge/internal/dto"
 	"github.com/umarquez/cryptocoins-go-challenge/internal/repository"
 	"github.com/umarquez/cryptocoins-go-challenge/internal/service"
 	"github.com/umarquez/cryptocoins-go-challenge/internal/usecase"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/bitso_client"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/cassette"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
 
 	"github.com/tidwall/buntdb"
 )
@@ -34,11 +43,27 @@ func Test_cryptoUseCase_GetCryptos(t *testing.T) {
 			}
 			defer db.Close()
 
-			srv := service.GetCryptoService()
+			// The Bitso responses are replayed from testdata, so the test runs
+			// offline. Run it with CASSETTE_MODE=record to refresh them.
+			mode, err := cassette.ModeFromEnv()
+			if err != nil {
+				t.Fatalf("cassette.ModeFromEnv() error = %v", err)
+			}
+			rec, err := cassette.New("testdata/bitso_tickers.json", mode)
+			if err != nil {
+				t.Fatalf("cassette.New() error = %v", err)
+			}
+			defer func() {
+				if err := rec.Stop(); err != nil {
+					t.Errorf("cassette.Stop() error = %v", err)
+				}
+			}()
+
+			srv := service.NewCryptoService(service.NewBitsoProvider(bitso_client.NewClient(bitso_client.WithTransport(rec))))
 			repo := repository.NewCryptoRepository(db, new(sync.Mutex), time.Minute)
 
 			uc := usecase.NewCryptoUseCase(srv, repo)
-			got, err := uc.GetAllCryptos()
+			got, err := uc.GetAllCryptos(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetCryptos() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -48,6 +73,82 @@ func Test_cryptoUseCase_GetCryptos(t *testing.T) {
 				t.Errorf("GetCryptos() got = %v, want %v", got, tt.want)
 				return
 			}
+
+			for _, c := range got {
+				if c.Price.MXN == "" || c.Price.USD == "" {
+					t.Errorf("GetCryptos() got an empty price for %s: %+v", c.TickerSymbol, c.Price)
+				}
+				if c.TickerSymbol == string(domain.BTC) && c.Price.MXN != "1195432.10" {
+					t.Errorf("GetCryptos() BTC/MXN = %s, want 1195432.10", c.Price.MXN)
+				}
+			}
 		})
 	}
 }
+
+// fixedProvider quotes fixed prices, and only the pairs it's given.
+type fixedProvider map[service.Pair]string
+
+func (p fixedProvider) Name() string {
+	return "fixed"
+}
+
+func (p fixedProvider) SupportedPairs() []service.Pair {
+	pairs := make([]service.Pair, 0, len(p))
+	for pair := range p {
+		pairs = append(pairs, pair)
+	}
+
+	return pairs
+}
+
+func (p fixedProvider) GetQuote(_ context.Context, crypto domain.CryptoCurrency, currency domain.Currency) (service.Quote, error) {
+	pair := service.Pair{Crypto: crypto, Currency: currency}
+	price, ok := p[pair]
+	if !ok {
+		return service.Quote{}, fmt.Errorf("%w: %s", service.ErrUnsupportedPair, pair)
+	}
+
+	return service.Quote{Pair: pair, Price: decimal.MustParse(price), Provider: p.Name(), Time: time.Now()}, nil
+}
+
+func Test_cryptoUseCase_SyntheticPrices(t *testing.T) {
+	db, err := buntdb.Open(":memory:")
+	if err != nil {
+		t.Fatalf("buntdb.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	// XRP/MXN isn't listed, it's derived through USD.
+	srv := service.NewCryptoService(fixedProvider{
+		{Crypto: domain.XRP, Currency: domain.USD}: "0.6286",
+		{Crypto: "USD", Currency: domain.MXN}:      "16.7877",
+	})
+	repo := repository.NewCryptoRepository(db, new(sync.Mutex), time.Minute)
+	uc := usecase.NewCryptoUseCase(srv, repo)
+
+	// The second request is served from the repository, which keeps the paths.
+	for _, source := range []string{"service", "repository"} {
+		got, err := uc.GetCryptoById(context.Background(), domain.CryptoIdEnum[domain.XRP])
+		if err != nil {
+			t.Fatalf("GetCryptoById() from %s error = %v", source, err)
+		}
+
+		want := domain.Price{USD: "0.6286", MXN: "10.5527", Synthetic: map[string]string{"mxn": "XRP→USD→MXN"}}
+		if !reflect.DeepEqual(got.Price, want) {
+			t.Errorf("GetCryptoById() from %s price = %+v, want %+v", source, got.Price, want)
+		}
+
+		normalized, err := dto.NormalizeCrypto(got)
+		if err != nil {
+			t.Fatalf("NormalizeCrypto() error = %v", err)
+		}
+		body, err := json.Marshal(normalized)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		if !strings.Contains(string(body), `"synthetic":{"mxn":"XRP→USD→MXN"}`) {
+			t.Errorf("response from %s = %s, want the synthetic MXN price annotated", source, body)
+		}
+	}
+}
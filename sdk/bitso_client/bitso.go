@@ -1,25 +1,39 @@
 // Description: This package provides a client to interact with the Bitso API.
 // The Bitso API documentation can be found at https://bitso.com/api_info.
 // The Bitso API provides the following endpoints:
-// - Ticker: Retrieve the ticker for the given cryptocurrency.
+// - Ticker: Retrieve the ticker for the given cryptocurrency, or for all of them.
 // - Order Book: Retrieve the order book for the given cryptocurrency.
 // - Trades: Retrieve the trades for the given cryptocurrency.
+// - OHLC: Retrieve the candles for the given cryptocurrency.
 // - Available Books: Retrieve the available books.
-// The Bitso API returns the data in JSON format.
+// - Private: Retrieve the balance, fees, ledger and open orders of the user.
+// - Trading: Place, cancel and look up orders (see NewTradingClient).
+// The Bitso API returns the data in JSON format; prices and amounts are
+// exposed as decimal.Decimal to keep their precision.
+// The Bitso WebSocket API streams the trades, diff-orders and orders
+// channels (see NewStream).
+// The client talks to the Production, Stage or Sandbox environments, or to a
+// Custom URL (see Environment).
+// The requests are rate limited on the client side to stay within the
+// Bitso limits (see RateLimiter).
+// The bitsotest package provides a fake Bitso server for the tests of the
+// code built on this client.
 //
 // author: Uriel Marquez (uriel.marquez@wizeline.com)
 // version: 0.1.0
 package bitso_client
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
-)
 
-const bitsoProdBaseUrl = "https://api-stage.bitso.com/api/v3"
-const bitsoSandboxBaseUrl = "https://api-sandbox.bitso.com/api/v3"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
 
 // BitsoError represents the error data.
 type BitsoError struct {
@@ -32,8 +46,33 @@ type bitsoBaseResponse struct {
 	Error   BitsoError `json:"error,omitempty"`
 }
 
+// bitsoResponse is implemented by every response envelope, so the success
+// flag and the API error can be checked in a single place.
+type bitsoResponse interface {
+	isSuccess() bool
+	apiError() BitsoError
+}
+
+func (r bitsoBaseResponse) isSuccess() bool {
+	return r.Success
+}
+
+func (r bitsoBaseResponse) apiError() BitsoError {
+	return r.Error
+}
+
 type bitsoClient struct {
-	baseUrl string
+	env        Environment
+	httpClient *http.Client
+	timeout    time.Duration
+	headers    http.Header
+	userAgent  string
+	books      *bookCatalog
+	limiter    *RateLimiter
+
+	credentials        CredentialsProvider
+	nonce              nonceGenerator
+	signedEnvironments []string
 }
 
 // TickerName represents the name of a ticker.
@@ -49,17 +88,17 @@ const (
 )
 
 type bitsoPayload struct {
-	High                 string            `json:"high"`
-	Last                 string            `json:"last"`
-	CreatedAt            time.Time         `json:"created_at"`
-	Book                 string            `json:"book"`
-	Volume               string            `json:"volume"`
-	Vwap                 string            `json:"vwap"`
-	Low                  string            `json:"low"`
-	Ask                  string            `json:"ask"`
-	Bid                  string            `json:"bid"`
-	Change24             string            `json:"change_24"`
-	RollingAverageChange map[string]string `json:"rolling_average_change"`
+	High                 decimal.Decimal            `json:"high"`
+	Last                 decimal.Decimal            `json:"last"`
+	CreatedAt            time.Time                  `json:"created_at"`
+	Book                 string                     `json:"book"`
+	Volume               decimal.Decimal            `json:"volume"`
+	Vwap                 decimal.Decimal            `json:"vwap"`
+	Low                  decimal.Decimal            `json:"low"`
+	Ask                  decimal.Decimal            `json:"ask"`
+	Bid                  decimal.Decimal            `json:"bid"`
+	Change24             decimal.Decimal            `json:"change_24"`
+	RollingAverageChange map[string]decimal.Decimal `json:"rolling_average_change"`
 }
 
 // Ticker represents the ticker data.
@@ -68,57 +107,185 @@ type Ticker struct {
 	Payload bitsoPayload `json:"payload"`
 }
 
+// OrderBookEntry represents a single level (aggregated mode) or a single
+// order (unaggregated mode) of the order book.
+// The Oid field is only populated by the unaggregated order book.
+type OrderBookEntry struct {
+	Book   string          `json:"book"`
+	Price  decimal.Decimal `json:"price"`
+	Amount decimal.Decimal `json:"amount"`
+	Oid    string          `json:"oid,omitempty"`
+}
+
+type bitsoOrderBookPayload struct {
+	Asks      []OrderBookEntry `json:"asks"`
+	Bids      []OrderBookEntry `json:"bids"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Sequence  int64            `json:"sequence,string"`
+}
+
 // OrderBook represents the order book data.
-// TODO: Implement the OrderBook struct.
-type OrderBook struct{}
+// Asks are sorted by price ascending and bids by price descending, as
+// returned by the API.
+type OrderBook struct {
+	bitsoBaseResponse
+	Payload bitsoOrderBookPayload `json:"payload"`
+}
 
 // Trade represents the trade data.
-// TODO: Implement the Trade struct.
-type Trade struct{}
+// MakerSide is the side of the maker order ("buy" or "sell").
+type Trade struct {
+	Book      string          `json:"book"`
+	CreatedAt time.Time       `json:"created_at"`
+	Amount    decimal.Decimal `json:"amount"`
+	MakerSide string          `json:"maker_side"`
+	Price     decimal.Decimal `json:"price"`
+	Tid       int64           `json:"tid"`
+}
+
+type bitsoTradesResponse struct {
+	bitsoBaseResponse
+	Payload []Trade `json:"payload"`
+}
+
+// SortDirection represents the sorting direction of paginated results.
+type SortDirection string
 
-// Book represents the book data.
-// TODO: Implement the Book struct.
-type Book struct{}
+const (
+	SortAsc  SortDirection = "asc"  // SortAsc for oldest records first.
+	SortDesc SortDirection = "desc" // SortDesc for newest records first (API default).
+)
+
+// MaxTradesLimit is the maximum number of trades the API returns per page.
+const MaxTradesLimit = 100
+
+// TradesParams represents the pagination parameters of the trades endpoint.
+// Zero values are omitted from the request, so the API defaults apply
+// (desc sort and 25 records per page).
+type TradesParams struct {
+	Marker int64         // Marker returns the trades older (desc) or newer (asc) than the given tid.
+	Sort   SortDirection // Sort is the sorting direction by tid.
+	Limit  int           // Limit is the number of trades to return (max. MaxTradesLimit).
+}
+
+// BookFee represents a fee tier of a book, applied after the given 30-day
+// traded volume is reached.
+type BookFee struct {
+	Volume decimal.Decimal `json:"volume"`
+	Maker  decimal.Decimal `json:"maker"`
+	Taker  decimal.Decimal `json:"taker"`
+}
+
+// BookFees represents the fees of a book.
+type BookFees struct {
+	FlatRate  BookFee   `json:"flat_rate"`
+	Structure []BookFee `json:"structure"`
+}
+
+// Book represents the book data and its order placement limits.
+// Amounts are expressed in the major currency, prices and values in the
+// minor currency (ex. BTC and MXN respectively for btc_mxn).
+type Book struct {
+	Book          string          `json:"book"`
+	MinimumAmount decimal.Decimal `json:"minimum_amount"`
+	MaximumAmount decimal.Decimal `json:"maximum_amount"`
+	MinimumPrice  decimal.Decimal `json:"minimum_price"`
+	MaximumPrice  decimal.Decimal `json:"maximum_price"`
+	MinimumValue  decimal.Decimal `json:"minimum_value"`
+	MaximumValue  decimal.Decimal `json:"maximum_value"`
+	TickSize      decimal.Decimal `json:"tick_size"`
+	Fees          BookFees        `json:"fees"`
+}
+
+// RoundToTick returns price rounded to a multiple of the tick size of the
+// book, so it can be used as the price of a limit order (ex. round buy
+// prices with decimal.RoundFloor and sell prices with decimal.RoundCeiling).
+func (b Book) RoundToTick(price decimal.Decimal, mode decimal.RoundingMode) decimal.Decimal {
+	return price.RoundToStep(b.TickSize, mode)
+}
+
+type bitsoAvailableBooksResponse struct {
+	bitsoBaseResponse
+	Payload []Book `json:"payload"`
+}
 
 // Client represents the Bitso client.
+// The *Context variants pass the cancellation and deadline of the given
+// context down to the HTTP requests; the rest of the methods use
+// context.Background().
 type Client interface {
 	GetTicker(ticker TickerName) (Ticker, error)
+	GetTickerContext(ctx context.Context, ticker TickerName) (Ticker, error)
+	GetAllTickers() (map[TickerName]Ticker, error)
+	GetAllTickersContext(ctx context.Context) (map[TickerName]Ticker, error)
 	GetOrderBook(ticker TickerName) (OrderBook, error)
+	GetOrderBookContext(ctx context.Context, ticker TickerName) (OrderBook, error)
+	GetUnaggregatedOrderBook(ticker TickerName) (OrderBook, error)
+	GetUnaggregatedOrderBookContext(ctx context.Context, ticker TickerName) (OrderBook, error)
 	GetTrades(ticker TickerName) ([]Trade, error)
+	GetTradesContext(ctx context.Context, ticker TickerName) ([]Trade, error)
+	GetTradesPage(ticker TickerName, params TradesParams) ([]Trade, error)
+	GetTradesPageContext(ctx context.Context, ticker TickerName, params TradesParams) ([]Trade, error)
+	GetOHLC(ticker TickerName, bucket TimeBucket, start, end time.Time) ([]Candle, error)
+	GetOHLCContext(ctx context.Context, ticker TickerName, bucket TimeBucket, start, end time.Time) ([]Candle, error)
 	GetAvailableBooks() ([]Book, error)
+	GetAvailableBooksContext(ctx context.Context) ([]Book, error)
+	GetBook(ticker TickerName) (Book, error)
+	GetBookContext(ctx context.Context, ticker TickerName) (Book, error)
+	ValidateBook(ticker TickerName) error
+	ValidateBookContext(ctx context.Context, ticker TickerName) error
+	Environment() Environment
+	RateLimit() []RateLimitState
 }
 
-// NewClient creates a new instance of the Bitso client.
-// If productionMode is true, it creates a client for the production environment.
-// Otherwise, it creates a client for the sandbox environment.
+// NewClient creates a new instance of the Bitso client configured with the
+// given options. By default, it creates a client for the sandbox environment
+// with a per-request timeout of 30 seconds and the available books cached for
+// an hour.
 // Please use the sandbox environment for dev and testing purposes, and the
-// production environment for real-world scenarios only.
+// production environment for real-world scenarios only: the private and
+// trading calls are refused in production unless it is allowed with
+// WithSignedEnvironments.
 // Example:
 //
-//	client := NewClient(productionMode=false)
+//	client := NewClient(
+//		WithTimeout(5*time.Second),
+//		WithUserAgent("my-app/1.0"),
+//	)
 //	ticker, err := client.GetTicker("btc_mxn")
 //	if err != nil {
 //		log.Fatal(err)
 //	}
-//	fmt.Printf("Last: %s\n", ticker.Last)
-//	fmt.Printf("Volume: %s\n", ticker.Volume)
-//	fmt.Printf("CreatedAt: %s\n", ticker.CreatedAt)
-//	fmt.Printf("Error: %v\n", ticker.Error)
-//	fmt.Printf("Success: %v\n", ticker.Success)
-//	fmt.Printf("High: %s\n", ticker.High)
-//	fmt.Printf("Book: %s\n", ticker.Book)
-//	fmt.Printf("Vwap: %s\n", ticker.Vwap)
-//	fmt.Printf("Low: %s\n", ticker.Low)
-//	fmt.Printf("Ask: %s\n", ticker.Ask)
-//	fmt.Printf("Bid: %s\n", ticker.Bid)
-//	fmt.Printf("Change24: %s\n", ticker.Change24)
-//	fmt.Printf("RollingAverageChange: %v\n", ticker.RollingAverageChange)
-func NewClient(productionMode bool) Client {
-	if productionMode {
-		return &bitsoClient{baseUrl: bitsoProdBaseUrl}
-	} else {
-		return &bitsoClient{baseUrl: bitsoSandboxBaseUrl}
+//	fmt.Printf("Last: %s\n", ticker.Payload.Last)
+//	fmt.Printf("Volume: %s\n", ticker.Payload.Volume)
+//	fmt.Printf("CreatedAt: %s\n", ticker.Payload.CreatedAt)
+func NewClient(opts ...Option) Client {
+	c := &bitsoClient{
+		env:        Sandbox,
+		httpClient: &http.Client{},
+		timeout:    defaultTimeout,
+		headers:    make(http.Header),
+		userAgent:  defaultUserAgent,
+		books:      newBookCatalog(defaultBookCatalogTTL),
+		limiter:    NewRateLimiter(nil),
+
+		signedEnvironments: defaultSignedEnvironments,
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
+}
+
+// NewClientWithMode creates a new instance of the Bitso client.
+// If productionMode is true, it creates a client for the production environment.
+// Otherwise, it creates a client for the sandbox environment.
+//
+// Deprecated: use NewClient with WithEnvironment instead.
+func NewClientWithMode(productionMode bool) Client {
+	return NewClient(WithProductionMode(productionMode))
 }
 
 // getTicker calls `<bitsoBaseUrl>/ticker?book=<name>` to retrieve the ticker
@@ -143,66 +310,293 @@ func NewClient(productionMode bool) Client {
 //			"rolling_average_change": {}
 //		}
 //	}
-func (c *bitsoClient) getTicker(name TickerName) (t Ticker, err error) {
-	url := fmt.Sprintf("%s/ticker?book=%s", c.baseUrl, name)
-	resp, err := http.Get(url)
-	if err != nil {
+func (c *bitsoClient) getTicker(ctx context.Context, name TickerName) (t Ticker, err error) {
+	if err = c.checkBook(ctx, name); err != nil {
 		return t, fmt.Errorf("failed to get ticker: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return t, fmt.Errorf("failed to get ticker: %s", resp.Status)
+	params := url.Values{}
+	params.Set("book", string(name))
+	if err = c.get(ctx, "/ticker", params, &t); err != nil {
+		return t, fmt.Errorf("failed to get ticker: %w", err)
 	}
 
-	defer resp.Body.Close()
+	return t, nil
+}
 
-	/*// read the response body and decode it into the Ticker struct
-	content, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return t, fmt.Errorf("failed to read response: %w", err)
-	}
-	_ = content*/
+type bitsoAllTickersResponse struct {
+	bitsoBaseResponse
+	Payload []bitsoPayload `json:"payload"`
+}
 
-	if err = json.NewDecoder(resp.Body).Decode(&t); err != nil {
-		return t, fmt.Errorf("failed to decode response: %w", err)
+// getAllTickers calls `<bitsoBaseUrl>/ticker` without a book to retrieve the
+// ticker data of every book in a single request and returns the result if
+// "success" == true.
+// Otherwise, it returns an error.
+// The response has the same fields as getTicker, with a list of payloads.
+func (c *bitsoClient) getAllTickers(ctx context.Context) (map[TickerName]Ticker, error) {
+	var resp bitsoAllTickersResponse
+	if err := c.get(ctx, "/ticker", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get tickers: %w", err)
 	}
 
-	if !t.Success {
-		return t, fmt.Errorf("failed to get ticker, API response: (%v)%s", t.Error.Code, t.Error.Message)
+	tickers := make(map[TickerName]Ticker, len(resp.Payload))
+	for _, p := range resp.Payload {
+		tickers[TickerName(p.Book)] = Ticker{
+			bitsoBaseResponse: resp.bitsoBaseResponse,
+			Payload:           p,
+		}
 	}
 
-	return t, nil
+	return tickers, nil
 }
 
-// getOrderBook calls `<bitsoBaseUrl>/order_book?book=<name>` to retrieve the order book
-// data and returns the result if "success" == true.
+// getOrderBook calls `<bitsoBaseUrl>/order_book?book=<name>&aggregate=<aggregate>`
+// to retrieve the order book data and returns the result if "success" == true.
 // Otherwise, it returns an error.
-// TODO: Implement the getOrderBook method.
-func (c *bitsoClient) getOrderBook(name TickerName) (ob OrderBook, err error) {
-	return
+// When aggregate is true, orders are grouped by price; otherwise every
+// order is listed with its own oid.
+// ref: https://docs.bitso.com/bitso-api/docs/list-order-book
+// Example response:
+//
+//	{
+//		"success": true,
+//		"payload": {
+//			"asks": [{
+//				"book": "btc_mxn",
+//				"price": "5632.24",
+//				"amount": "1.34491802"
+//			}],
+//			"bids": [{
+//				"book": "btc_mxn",
+//				"price": "6123.55",
+//				"amount": "1.12560000"
+//			}],
+//			"updated_at": "2016-04-08T17:52:31.000+00:00",
+//			"sequence": "27214"
+//		}
+//	}
+func (c *bitsoClient) getOrderBook(ctx context.Context, name TickerName, aggregate bool) (ob OrderBook, err error) {
+	if err = c.checkBook(ctx, name); err != nil {
+		return ob, fmt.Errorf("failed to get order book: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("book", string(name))
+	params.Set("aggregate", fmt.Sprint(aggregate))
+	if err = c.get(ctx, "/order_book", params, &ob); err != nil {
+		return ob, fmt.Errorf("failed to get order book: %w", err)
+	}
+
+	return ob, nil
+}
+
+// get calls `<bitsoBaseUrl><endpoint>?<params>` and decodes the response
+// into v. It returns an *APIError if the HTTP status is not 200 or the API
+// response is not successful, an error wrapping ErrInvalidResponse if the
+// response can't be decoded, and an error wrapping ErrUnavailable if the
+// request fails.
+// If ctx is canceled or its deadline is exceeded, the returned error wraps
+// context.Canceled or context.DeadlineExceeded, so callers can tell it apart
+// from an API error using errors.Is.
+func (c *bitsoClient) get(ctx context.Context, endpoint string, params url.Values, v bitsoResponse) error {
+	return c.do(ctx, http.MethodGet, endpoint, params, nil, false, v)
+}
+
+// do sends a request to `<bitsoBaseUrl><endpoint>?<params>`, with body
+// encoded as JSON (if not nil), and decodes the response into v.
+// If signed is true, the request is signed with the client credentials.
+// See get for the returned errors.
+func (c *bitsoClient) do(ctx context.Context, method, endpoint string, params url.Values, body any, signed bool, v bitsoResponse) error {
+	u := c.env.baseUrl + endpoint
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	class := PublicEndpoints
+	if signed {
+		class = PrivateEndpoints
+
+		// The private calls that would be refused don't take a rate limit
+		// token, nor wait for one.
+		if err := c.checkSignedEnvironment(); err != nil {
+			return err
+		}
+		if c.credentials == nil {
+			return ErrMissingCredentials
+		}
+	}
+
+	// Waiting for the rate limiter doesn't count against the request timeout.
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx, class); err != nil {
+			return err
+		}
+	}
+
+	parent := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if signed {
+		if err = c.sign(ctx, req, payload); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The caller gave up: report the cancellation as is.
+		if parent.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Failed requests usually carry the API error in the body.
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: resp.Status}
+		var errResp bitsoBaseResponse
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error.Code != "" {
+			apiErr.Code = errResp.Error.Code
+			apiErr.Message = errResp.Error.Message
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// Hold the next requests until the server lets us back in.
+			retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				retryAfter = defaultRetryAfter
+			}
+			apiErr.RetryAfter = retryAfter
+			if c.limiter != nil {
+				c.limiter.Pause(class, retryAfter)
+			}
+		}
+		return apiErr
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	if !v.isSuccess() {
+		apiErr := v.apiError()
+		return &APIError{StatusCode: resp.StatusCode, Code: apiErr.Code, Message: apiErr.Message}
+	}
+
+	return nil
 }
 
 // getAvailableBooks calls `<bitsoBaseUrl>/available_books` to retrieve the available books
 // data and returns the result if "success" == true.
 // Otherwise, it returns an error.
-// TODO: Implement the getAvailableBooks method.
-func (c *bitsoClient) getAvailableBooks() (b []Book, err error) {
-	return
+// ref: https://docs.bitso.com/bitso-api/docs/list-available-books
+// Example response:
+//
+//	{
+//		"success": true,
+//		"payload": [{
+//			"book": "btc_mxn",
+//			"minimum_amount": ".003",
+//			"maximum_amount": "1000.00",
+//			"minimum_price": "100.00",
+//			"maximum_price": "1000000.00",
+//			"minimum_value": "25.00",
+//			"maximum_value": "1000000.00",
+//			"tick_size": "0.01",
+//			"fees": {
+//				"flat_rate": {"maker": "0.500", "taker": "0.650"},
+//				"structure": [{"volume": "1500000", "maker": "0.00500", "taker": "0.00650"}]
+//			}
+//		}]
+//	}
+func (c *bitsoClient) getAvailableBooks(ctx context.Context) (b []Book, err error) {
+	var resp bitsoAvailableBooksResponse
+	if err = c.get(ctx, "/available_books", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get available books: %w", err)
+	}
+
+	return resp.Payload, nil
 }
 
-// getTrades calls `<bitsoBaseUrl>/trades?book=<name>` to retrieve the trades
-// data and returns the result if "success" == true.
+// getTrades calls `<bitsoBaseUrl>/trades?book=<name>&marker=<marker>&sort=<sort>&limit=<limit>`
+// to retrieve the trades data and returns the result if "success" == true.
 // Otherwise, it returns an error.
-// TODO: Implement the getTrades method.
-func (c *bitsoClient) getTrades(name TickerName) (t []Trade, err error) {
-	return
+// ref: https://docs.bitso.com/bitso-api/docs/list-trades
+// Example response:
+//
+//	{
+//		"success": true,
+//		"payload": [{
+//			"book": "btc_mxn",
+//			"created_at": "2016-04-08T17:52:31.000+00:00",
+//			"amount": "0.02000000",
+//			"maker_side": "buy",
+//			"price": "5545.01",
+//			"tid": 55845
+//		}]
+//	}
+func (c *bitsoClient) getTrades(ctx context.Context, name TickerName, p TradesParams) (t []Trade, err error) {
+	if err = c.checkBook(ctx, name); err != nil {
+		return nil, fmt.Errorf("failed to get trades: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("book", string(name))
+	if p.Marker > 0 {
+		params.Set("marker", fmt.Sprint(p.Marker))
+	}
+	if p.Sort != "" {
+		params.Set("sort", string(p.Sort))
+	}
+	if p.Limit > 0 {
+		params.Set("limit", fmt.Sprint(p.Limit))
+	}
+
+	var resp bitsoTradesResponse
+	if err = c.get(ctx, "/trades", params, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get trades: %w", err)
+	}
+
+	return resp.Payload, nil
 }
 
 // GetTicker retrieves the ticker for the given cryptocurrency.
 // It returns an error if the request fails or if the API response is not successful.
 // Otherwise, it returns the ticker data.
 // The TickerName is the name of the cryptocurrency (ex. BTC_MXN).
-// The Ticker struct contains the following fields:
+// The Ticker struct contains the Success and Error fields, and the Payload
+// with the following fields:
 // - High
 // - Last
 // - CreatedAt
@@ -214,10 +608,10 @@ func (c *bitsoClient) getTrades(name TickerName) (t []Trade, err error) {
 // - Bid
 // - Change24
 // - RollingAverageChange
-// - Success
-// - Error (if the request fails)
 // The CreatedAt field is a time.Time value.
-// The RollingAverageChange field is a map[string]string.
+// The numeric fields (High, Last, Volume, Vwap, Low, Ask, Bid and Change24)
+// are decimal.Decimal values.
+// The RollingAverageChange field is a map[string]decimal.Decimal.
 // The Success field is a boolean.
 // The Error field is a BitsoError.
 // The BitsoError struct contains the following fields:
@@ -229,19 +623,19 @@ func (c *bitsoClient) getTrades(name TickerName) (t []Trade, err error) {
 //	if err != nil {
 //		log.Fatal(err)
 //	}
-//	fmt.Printf("Last: %s\n", ticker.Last)
-//	fmt.Printf("Volume: %s\n", ticker.Volume)
-//	fmt.Printf("CreatedAt: %s\n", ticker.CreatedAt)
+//	fmt.Printf("Last: %s\n", ticker.Payload.Last)
+//	fmt.Printf("Volume: %s\n", ticker.Payload.Volume)
+//	fmt.Printf("CreatedAt: %s\n", ticker.Payload.CreatedAt)
 //	fmt.Printf("Error: %v\n", ticker.Error)
 //	fmt.Printf("Success: %v\n", ticker.Success)
-//	fmt.Printf("High: %s\n", ticker.High)
-//	fmt.Printf("Book: %s\n", ticker.Book)
-//	fmt.Printf("Vwap: %s\n", ticker.Vwap)
-//	fmt.Printf("Low: %s\n", ticker.Low)
-//	fmt.Printf("Ask: %s\n", ticker.Ask)
-//	fmt.Printf("Bid: %s\n", ticker.Bid)
-//	fmt.Printf("Change24: %s\n", ticker.Change24)
-//	fmt.Printf("RollingAverageChange: %v\n", ticker.RollingAverageChange)
+//	fmt.Printf("High: %s\n", ticker.Payload.High)
+//	fmt.Printf("Book: %s\n", ticker.Payload.Book)
+//	fmt.Printf("Vwap: %s\n", ticker.Payload.Vwap)
+//	fmt.Printf("Low: %s\n", ticker.Payload.Low)
+//	fmt.Printf("Ask: %s\n", ticker.Payload.Ask)
+//	fmt.Printf("Bid: %s\n", ticker.Payload.Bid)
+//	fmt.Printf("Change24: %s\n", ticker.Payload.Change24)
+//	fmt.Printf("RollingAverageChange: %v\n", ticker.Payload.RollingAverageChange)
 //
 // The output should be:
 //
@@ -261,23 +655,93 @@ func (c *bitsoClient) getTrades(name TickerName) (t []Trade, err error) {
 //
 // The CreatedAt field should be a time.Time value.
 // The Error field should be a BitsoError.
-// The RollingAverageChange field should be a map[string]string.
+// The RollingAverageChange field should be a map[string]decimal.Decimal.
 // The Success field should be a boolean.
 func (c *bitsoClient) GetTicker(ticker TickerName) (Ticker, error) {
-	return c.getTicker(ticker)
+	return c.GetTickerContext(context.Background(), ticker)
+}
+
+// GetTickerContext retrieves the ticker for the given cryptocurrency,
+// canceling the request when ctx is done.
+// See GetTicker for details.
+func (c *bitsoClient) GetTickerContext(ctx context.Context, ticker TickerName) (Ticker, error) {
+	return c.getTicker(ctx, ticker)
 }
 
-// GetOrderBook retrieves the order book for the given cryptocurrency.
+// GetAllTickers retrieves the tickers of every available book in a single
+// request, keyed by book name.
+// Example:
+//
+//	tickers, err := client.GetAllTickers()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Printf("BTC/MXN: %s\n", tickers[BTC_MXN].Payload.Last)
+func (c *bitsoClient) GetAllTickers() (map[TickerName]Ticker, error) {
+	return c.GetAllTickersContext(context.Background())
+}
+
+// GetAllTickersContext retrieves the tickers of every available book,
+// canceling the request when ctx is done.
+func (c *bitsoClient) GetAllTickersContext(ctx context.Context) (map[TickerName]Ticker, error) {
+	return c.getAllTickers(ctx)
+}
+
+// GetOrderBook retrieves the aggregated order book for the given
+// cryptocurrency, where each entry represents a price level.
 func (c *bitsoClient) GetOrderBook(ticker TickerName) (OrderBook, error) {
-	return c.getOrderBook(ticker)
+	return c.GetOrderBookContext(context.Background(), ticker)
 }
 
-// GetTrades retrieves the trades for the given cryptocurrency.
+// GetOrderBookContext retrieves the aggregated order book for the given
+// cryptocurrency, canceling the request when ctx is done.
+func (c *bitsoClient) GetOrderBookContext(ctx context.Context, ticker TickerName) (OrderBook, error) {
+	return c.getOrderBook(ctx, ticker, true)
+}
+
+// GetUnaggregatedOrderBook retrieves the unaggregated order book for the given
+// cryptocurrency, where each entry represents a single order and carries its oid.
+func (c *bitsoClient) GetUnaggregatedOrderBook(ticker TickerName) (OrderBook, error) {
+	return c.GetUnaggregatedOrderBookContext(context.Background(), ticker)
+}
+
+// GetUnaggregatedOrderBookContext retrieves the unaggregated order book for
+// the given cryptocurrency, canceling the request when ctx is done.
+func (c *bitsoClient) GetUnaggregatedOrderBookContext(ctx context.Context, ticker TickerName) (OrderBook, error) {
+	return c.getOrderBook(ctx, ticker, false)
+}
+
+// GetTrades retrieves the latest trades for the given cryptocurrency.
 func (c *bitsoClient) GetTrades(ticker TickerName) ([]Trade, error) {
-	return c.getTrades(ticker)
+	return c.GetTradesContext(context.Background(), ticker)
+}
+
+// GetTradesContext retrieves the latest trades for the given cryptocurrency,
+// canceling the request when ctx is done.
+func (c *bitsoClient) GetTradesContext(ctx context.Context, ticker TickerName) ([]Trade, error) {
+	return c.getTrades(ctx, ticker, TradesParams{})
+}
+
+// GetTradesPage retrieves a page of trades for the given cryptocurrency.
+// Use the tid of the last trade as the Marker of the next page.
+// See NewTradeIterator to walk through the history without handling markers.
+func (c *bitsoClient) GetTradesPage(ticker TickerName, params TradesParams) ([]Trade, error) {
+	return c.GetTradesPageContext(context.Background(), ticker, params)
+}
+
+// GetTradesPageContext retrieves a page of trades for the given
+// cryptocurrency, canceling the request when ctx is done.
+func (c *bitsoClient) GetTradesPageContext(ctx context.Context, ticker TickerName, params TradesParams) ([]Trade, error) {
+	return c.getTrades(ctx, ticker, params)
 }
 
 // GetAvailableBooks retrieves the available books.
 func (c *bitsoClient) GetAvailableBooks() ([]Book, error) {
-	return c.getAvailableBooks()
+	return c.GetAvailableBooksContext(context.Background())
+}
+
+// GetAvailableBooksContext retrieves the available books, canceling the
+// request when ctx is done.
+func (c *bitsoClient) GetAvailableBooksContext(ctx context.Context) ([]Book, error) {
+	return c.getAvailableBooks(ctx)
 }
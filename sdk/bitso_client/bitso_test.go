@@ -1,9 +1,18 @@
 package bitso_client
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/cassette"
 )
 
 func TestGetTicker(t *testing.T) {
@@ -38,7 +47,20 @@ func TestGetTicker(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client := NewClient(false)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/ticker" {
+					http.NotFound(w, r)
+					return
+				}
+				if got := r.URL.Query().Get("book"); got != string(tt.tickerName) {
+					t.Errorf("book = %s, want %s", got, tt.tickerName)
+				}
+				w.WriteHeader(tt.serverStatus)
+				_ = json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer srv.Close()
+
+			client := NewClient(WithBaseUrl(srv.URL))
 
 			got, err := client.GetTicker(tt.tickerName)
 			if (err != nil) != tt.wantErr {
@@ -61,3 +83,393 @@ func TestGetTicker(t *testing.T) {
 		})
 	}
 }
+
+func TestGetOrderBook(t *testing.T) {
+	tests := []struct {
+		name          string
+		aggregate     bool
+		serverStatus  int
+		response      string
+		wantErr       bool
+		wantAsks      int
+		wantBids      int
+		wantOid       string
+		wantSequence  int64
+		wantAggregate string
+	}{
+		{
+			name:         "aggregated order book",
+			aggregate:    true,
+			serverStatus: http.StatusOK,
+			response: `{"success": true, "payload": {
+				"asks": [{"book": "btc_mxn", "price": "5632.24", "amount": "1.34491802"}],
+				"bids": [{"book": "btc_mxn", "price": "5600.00", "amount": "0.5"},
+				         {"book": "btc_mxn", "price": "5590.00", "amount": "1.5"}],
+				"updated_at": "2016-04-08T17:52:31.000+00:00",
+				"sequence": "27214"}}`,
+			wantAsks:      1,
+			wantBids:      2,
+			wantSequence:  27214,
+			wantAggregate: "true",
+		},
+		{
+			name:         "unaggregated order book",
+			aggregate:    false,
+			serverStatus: http.StatusOK,
+			response: `{"success": true, "payload": {
+				"asks": [{"book": "btc_mxn", "price": "5632.24", "amount": "1.34491802", "oid": "Gl9ll1aTnf"}],
+				"bids": [],
+				"updated_at": "2016-04-08T17:52:31.000+00:00",
+				"sequence": "27215"}}`,
+			wantAsks:      1,
+			wantOid:       "Gl9ll1aTnf",
+			wantSequence:  27215,
+			wantAggregate: "false",
+		},
+		{
+			name:          "unsuccessful response",
+			aggregate:     true,
+			serverStatus:  http.StatusOK,
+			response:      `{"success": false, "error": {"code": "0301", "message": "Unknown OrderBook btc_btc"}}`,
+			wantErr:       true,
+			wantAggregate: "true",
+		},
+		{
+			name:          "server error",
+			aggregate:     true,
+			serverStatus:  http.StatusInternalServerError,
+			response:      ``,
+			wantErr:       true,
+			wantAggregate: "true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/order_book" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				if got := r.URL.Query().Get("aggregate"); got != tt.wantAggregate {
+					t.Errorf("aggregate = %s, want %s", got, tt.wantAggregate)
+				}
+				w.WriteHeader(tt.serverStatus)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0))
+
+			var got OrderBook
+			var err error
+			if tt.aggregate {
+				got, err = client.GetOrderBook(BTC_MXN)
+			} else {
+				got, err = client.GetUnaggregatedOrderBook(BTC_MXN)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getOrderBook() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(got.Payload.Asks) != tt.wantAsks || len(got.Payload.Bids) != tt.wantBids {
+				t.Errorf("getOrderBook() got %d asks and %d bids, want %d and %d",
+					len(got.Payload.Asks), len(got.Payload.Bids), tt.wantAsks, tt.wantBids)
+			}
+
+			if got.Payload.Sequence != tt.wantSequence {
+				t.Errorf("getOrderBook() sequence = %d, want %d", got.Payload.Sequence, tt.wantSequence)
+			}
+
+			if got.Payload.Asks[0].Oid != tt.wantOid {
+				t.Errorf("getOrderBook() oid = %s, want %s", got.Payload.Asks[0].Oid, tt.wantOid)
+			}
+
+			if got.Payload.UpdatedAt.IsZero() {
+				t.Errorf("getOrderBook() updated_at was not decoded")
+			}
+		})
+	}
+}
+
+func TestGetTradesPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     TradesParams
+		wantQuery  string
+		response   string
+		wantErr    bool
+		wantTrades int
+	}{
+		{
+			name:      "default parameters",
+			params:    TradesParams{},
+			wantQuery: "book=btc_mxn",
+			response: `{"success": true, "payload": [{
+				"book": "btc_mxn", "created_at": "2016-04-08T17:52:31.000+00:00",
+				"amount": "0.02000000", "maker_side": "buy", "price": "5545.01", "tid": 55845}]}`,
+			wantTrades: 1,
+		},
+		{
+			name:       "pagination parameters",
+			params:     TradesParams{Marker: 55845, Sort: SortAsc, Limit: 50},
+			wantQuery:  "book=btc_mxn&limit=50&marker=55845&sort=asc",
+			response:   `{"success": true, "payload": []}`,
+			wantTrades: 0,
+		},
+		{
+			name:      "unsuccessful response",
+			params:    TradesParams{},
+			wantQuery: "book=btc_mxn",
+			response:  `{"success": false, "error": {"code": "0301", "message": "Unknown OrderBook"}}`,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/trades" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				if r.URL.RawQuery != tt.wantQuery {
+					t.Errorf("query = %s, want %s", r.URL.RawQuery, tt.wantQuery)
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0))
+			got, err := client.GetTradesPage(BTC_MXN, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getTrades() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if len(got) != tt.wantTrades {
+				t.Errorf("getTrades() got %d trades, want %d", len(got), tt.wantTrades)
+				return
+			}
+
+			if tt.wantTrades > 0 && (got[0].Tid != 55845 || got[0].MakerSide != "buy" || got[0].CreatedAt.IsZero()) {
+				t.Errorf("getTrades() got = %+v", got[0])
+			}
+		})
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	var gotHeader http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header
+		_, _ = w.Write([]byte(`{"success": true, "payload": {"book": "btc_mxn"}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(
+		WithBaseUrl(srv.URL),
+		WithBookCatalog(0),
+		WithUserAgent("test-agent/1.0"),
+		WithHeader("X-Test", "value"),
+	)
+	if _, err := client.GetTicker(BTC_MXN); err != nil {
+		t.Fatalf("GetTicker() error = %v", err)
+	}
+
+	if gotHeader.Get("User-Agent") != "test-agent/1.0" {
+		t.Errorf("User-Agent = %s, want test-agent/1.0", gotHeader.Get("User-Agent"))
+	}
+
+	if gotHeader.Get("X-Test") != "value" {
+		t.Errorf("X-Test = %s, want value", gotHeader.Get("X-Test"))
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0), WithTimeout(50*time.Millisecond))
+	if _, err := client.GetTicker(BTC_MXN); err == nil {
+		t.Errorf("GetTicker() error = nil, want a timeout error")
+	}
+}
+
+func TestClientDefaults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success": true, "payload": {"book": "btc_mxn"}}`))
+	}))
+	defer srv.Close()
+
+	// A nil HTTP client keeps the default one, and a timeout <= 0 disables
+	// the per-request timeout instead of failing every request.
+	for _, opts := range [][]Option{
+		{WithHttpClient(nil)},
+		{WithTimeout(0)},
+		{WithTimeout(-time.Second)},
+	} {
+		client := NewClient(append(opts, WithBaseUrl(srv.URL), WithBookCatalog(0))...)
+		if _, err := client.GetTicker(BTC_MXN); err != nil {
+			t.Errorf("GetTicker() error = %v", err)
+		}
+	}
+}
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestClientTransport(t *testing.T) {
+	var gotUrl string
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		gotUrl = r.URL.String()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"success": true, "payload": {"book": "eth_mxn"}}`)),
+			Request:    r,
+		}, nil
+	})
+
+	client := NewClient(WithBaseUrl("https://bitso.test/api/v3"), WithBookCatalog(0), WithTransport(transport))
+	got, err := client.GetTicker(ETH_MXN)
+	if err != nil {
+		t.Fatalf("GetTicker() error = %v", err)
+	}
+
+	if got.Payload.Book != string(ETH_MXN) {
+		t.Errorf("GetTicker() book = %s, want %s", got.Payload.Book, ETH_MXN)
+	}
+
+	if gotUrl != "https://bitso.test/api/v3/ticker?book=eth_mxn" {
+		t.Errorf("request url = %s", gotUrl)
+	}
+
+	// The transport replaces a nil HTTP client.
+	client = NewClient(WithBaseUrl("https://bitso.test/api/v3"), WithBookCatalog(0), WithHttpClient(nil), WithTransport(transport))
+	if _, err = client.GetTicker(ETH_MXN); err != nil {
+		t.Errorf("GetTicker() with a nil HTTP client error = %v", err)
+	}
+}
+
+func TestGetTickerContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{
+			name: "canceled context",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			client := NewClient(WithBaseUrl(srv.URL))
+			_, err := client.GetTickerContext(ctx, BTC_MXN)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetTickerContext() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetAllTickers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ticker" || r.URL.Query().Has("book") {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = io.WriteString(w, `{"success": true, "payload": [
+			{"book": "btc_mxn", "last": "512345.67", "ask": "512400.00", "bid": "512300.10"},
+			{"book": "eth_usd", "last": "3210.5", "ask": "3211", "bid": "3210"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithBaseUrl(srv.URL))
+	tickers, err := client.GetAllTickers()
+	if err != nil {
+		t.Fatalf("GetAllTickers() error = %v", err)
+	}
+
+	if len(tickers) != 2 {
+		t.Fatalf("GetAllTickers() got %d tickers, want 2", len(tickers))
+	}
+
+	btc := tickers[BTC_MXN]
+	if !btc.Success || btc.Payload.Book != string(BTC_MXN) || btc.Payload.Last.String() != "512345.67" {
+		t.Errorf("tickers[%s] = %+v", BTC_MXN, btc)
+	}
+
+	if eth := tickers[ETH_USD]; eth.Payload.Bid.String() != "3210" {
+		t.Errorf("tickers[%s] = %+v", ETH_USD, eth)
+	}
+}
+
+func TestGetTickerCassette(t *testing.T) {
+	// Run with CASSETTE_MODE=record to refresh the responses from the sandbox.
+	mode, err := cassette.ModeFromEnv()
+	if err != nil {
+		t.Fatalf("cassette.ModeFromEnv() error = %v", err)
+	}
+	rec, err := cassette.New("testdata/sandbox_ticker.json", mode)
+	if err != nil {
+		t.Fatalf("cassette.New() error = %v", err)
+	}
+	defer func() {
+		if err := rec.Stop(); err != nil {
+			t.Errorf("cassette.Stop() error = %v", err)
+		}
+	}()
+
+	client := NewClient(WithEnvironment(Sandbox), WithTransport(rec))
+	ticker, err := client.GetTicker(BTC_MXN)
+	if err != nil {
+		t.Fatalf("GetTicker() error = %v", err)
+	}
+
+	if !ticker.Success || ticker.Payload.Book != string(BTC_MXN) || ticker.Payload.Last.Sign() <= 0 {
+		t.Errorf("GetTicker() got = %+v", ticker)
+	}
+}
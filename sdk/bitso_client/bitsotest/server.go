@@ -0,0 +1,498 @@
+// Description: This package provides a programmable fake of the Bitso REST
+// API for the tests of the code built on bitso_client.
+// The fake serves the scripted tickers, order books, trades and available
+// books, can inject errors and latency, and keeps the requests it received
+// so the tests can assert on them.
+// Example:
+//
+//	srv := bitsotest.NewServer()
+//	defer srv.Close()
+//
+//	srv.SetTicker(bitso_client.BTC_MXN, bitsotest.Ticker{Last: decimal.MustParse("512345.67")})
+//	srv.Fail("/ticker", bitsotest.RateLimited(time.Second), 1)
+//
+//	client := srv.Client()
+//	_, err := client.GetTicker(bitso_client.BTC_MXN) // ErrRateLimited
+//	ticker, err := client.GetTicker(bitso_client.BTC_MXN)
+//
+// author: Uriel Marquez (uriel.marquez@wizeline.com)
+// version: 0.1.0
+package bitsotest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/bitso_client"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+const (
+	defaultTradesLimit = 25
+	maxTradesLimit     = bitso_client.MaxTradesLimit
+)
+
+// Ticker represents the scripted ticker of a book.
+// A zero CreatedAt is served as the time of the request.
+type Ticker struct {
+	High      decimal.Decimal
+	Last      decimal.Decimal
+	Low       decimal.Decimal
+	Ask       decimal.Decimal
+	Bid       decimal.Decimal
+	Volume    decimal.Decimal
+	Vwap      decimal.Decimal
+	Change24  decimal.Decimal
+	CreatedAt time.Time
+}
+
+// Fault represents an error served instead of the scripted response.
+// Status is the HTTP status (500 if not set); Code and Message are the Bitso
+// error of the body, which is omitted when both are empty. A Status of 200
+// serves a "success": false response.
+type Fault struct {
+	Status     int
+	Code       string
+	Message    string
+	RetryAfter time.Duration // RetryAfter sets the Retry-After header, in seconds.
+}
+
+// RateLimited returns a 429 fault with the given Retry-After.
+func RateLimited(retryAfter time.Duration) Fault {
+	return Fault{Status: http.StatusTooManyRequests, Code: "0201", Message: "Too many requests", RetryAfter: retryAfter}
+}
+
+// ServerError returns a 500 fault.
+func ServerError() Fault {
+	return Fault{Status: http.StatusInternalServerError, Code: "0101", Message: "Unknown error"}
+}
+
+// Unsuccessful returns a 200 fault with "success": false and the given error.
+func Unsuccessful(code, message string) Fault {
+	return Fault{Status: http.StatusOK, Code: code, Message: message}
+}
+
+// Request represents a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+type scriptedFault struct {
+	path  string
+	fault Fault
+	times int // times is the number of requests left to fail, or < 0 for all of them.
+}
+
+type orderBook struct {
+	bids     []bitso_client.OrderBookEntry
+	asks     []bitso_client.OrderBookEntry
+	sequence int64
+}
+
+// Server represents a fake Bitso REST server listening on a local address.
+// All the methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mutex      sync.Mutex
+	tickers    map[string]Ticker
+	orderBooks map[string]orderBook
+	trades     map[string][]bitso_client.Trade
+	books      []bitso_client.Book
+	faults     []*scriptedFault
+	latency    time.Duration
+	requests   []Request
+}
+
+// NewServer starts a fake Bitso server without data. The caller must call
+// Close when done.
+func NewServer() *Server {
+	s := &Server{
+		tickers:    make(map[string]Ticker),
+		orderBooks: make(map[string]orderBook),
+		trades:     make(map[string][]bitso_client.Trade),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Client creates a Bitso client of the server, configured with the given
+// options. The local validation of the books and the rate limiter are
+// disabled, so the client doesn't issue extra requests nor waits after an
+// injected 429; pass bitso_client.WithBookCatalog or
+// bitso_client.WithRateLimiter to enable them.
+func (s *Server) Client(opts ...bitso_client.Option) bitso_client.Client {
+	opts = append([]bitso_client.Option{
+		bitso_client.WithBaseUrl(s.URL),
+		bitso_client.WithBookCatalog(0),
+		bitso_client.WithRateLimiter(nil),
+	}, opts...)
+
+	return bitso_client.NewClient(opts...)
+}
+
+// SetTicker sets the ticker served for the given book.
+func (s *Server) SetTicker(book bitso_client.TickerName, ticker Ticker) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tickers[string(book)] = ticker
+}
+
+// SetOrderBook sets the orders served for the given book. The entries must
+// have an Oid; the aggregated order book groups them by price.
+func (s *Server) SetOrderBook(book bitso_client.TickerName, sequence int64, bids, asks []bitso_client.OrderBookEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.orderBooks[string(book)] = orderBook{bids: bids, asks: asks, sequence: sequence}
+}
+
+// SetTrades sets the trade history served for the given book, paginated by
+// tid as the API does.
+func (s *Server) SetTrades(book bitso_client.TickerName, trades []bitso_client.Trade) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sorted := append([]bitso_client.Trade(nil), trades...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Tid < sorted[j].Tid
+	})
+	s.trades[string(book)] = sorted
+}
+
+// SetBooks sets the available books. Until it is called, every book with a
+// scripted ticker, order book or trades is listed without limits.
+func (s *Server) SetBooks(books ...bitso_client.Book) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.books = append([]bitso_client.Book{}, books...)
+}
+
+// Fail serves the fault to the next `times` requests of the given path
+// (ex. "/ticker"), or to every request of the path if times <= 0.
+// An empty path matches every request.
+func (s *Server) Fail(path string, fault Fault, times int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if times <= 0 {
+		times = -1
+	}
+	s.faults = append(s.faults, &scriptedFault{path: path, fault: fault, times: times})
+}
+
+// ClearFaults removes the pending faults.
+func (s *Server) ClearFaults() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.faults = nil
+}
+
+// SetLatency sets the time the server waits before replying to each request.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.latency = latency
+}
+
+// Requests returns the requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestCount returns the number of requests received for the given path,
+// or for every path if path is empty.
+func (s *Server) RequestCount(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	for _, r := range s.requests {
+		if path == "" || r.Path == path {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ResetRequests forgets the requests received so far.
+func (s *Server) ResetRequests() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = nil
+}
+
+// serveHTTP records the request, applies the latency and faults, and routes
+// it to the scripted data.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	latency := s.latency
+	fault, failed := s.nextFault(r.URL.Path)
+	s.mutex.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if failed {
+		writeFault(w, fault)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	book := r.URL.Query().Get("book")
+	switch r.URL.Path {
+	case "/ticker":
+		s.serveTicker(w, book, r.URL.Query().Has("book"))
+	case "/order_book":
+		s.serveOrderBook(w, book, r.URL.Query().Get("aggregate") != "false")
+	case "/trades":
+		s.serveTrades(w, book, r.URL.Query())
+	case "/available_books":
+		writePayload(w, s.availableBooks())
+	default:
+		writeFault(w, Fault{Status: http.StatusNotFound, Code: "0100", Message: "Unknown endpoint " + r.URL.Path})
+	}
+}
+
+// nextFault returns the fault for the path, if any. The caller must hold the mutex.
+func (s *Server) nextFault(path string) (Fault, bool) {
+	for i, f := range s.faults {
+		if f.path != "" && f.path != path {
+			continue
+		}
+
+		if f.times > 0 {
+			f.times--
+			if f.times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+
+		return f.fault, true
+	}
+
+	return Fault{}, false
+}
+
+type tickerPayload struct {
+	High      decimal.Decimal `json:"high"`
+	Last      decimal.Decimal `json:"last"`
+	CreatedAt time.Time       `json:"created_at"`
+	Book      string          `json:"book"`
+	Volume    decimal.Decimal `json:"volume"`
+	Vwap      decimal.Decimal `json:"vwap"`
+	Low       decimal.Decimal `json:"low"`
+	Ask       decimal.Decimal `json:"ask"`
+	Bid       decimal.Decimal `json:"bid"`
+	Change24  decimal.Decimal `json:"change_24"`
+}
+
+func newTickerPayload(book string, t Ticker) tickerPayload {
+	createdAt := t.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	return tickerPayload{
+		High:      t.High,
+		Last:      t.Last,
+		CreatedAt: createdAt,
+		Book:      book,
+		Volume:    t.Volume,
+		Vwap:      t.Vwap,
+		Low:       t.Low,
+		Ask:       t.Ask,
+		Bid:       t.Bid,
+		Change24:  t.Change24,
+	}
+}
+
+func (s *Server) serveTicker(w http.ResponseWriter, book string, single bool) {
+	if !single {
+		payload := make([]tickerPayload, 0, len(s.tickers))
+		for name, t := range s.tickers {
+			payload = append(payload, newTickerPayload(name, t))
+		}
+		sort.Slice(payload, func(i, j int) bool {
+			return payload[i].Book < payload[j].Book
+		})
+		writePayload(w, payload)
+		return
+	}
+
+	t, ok := s.tickers[book]
+	if !ok {
+		writeUnknownBook(w, book)
+		return
+	}
+
+	writePayload(w, newTickerPayload(book, t))
+}
+
+func (s *Server) serveOrderBook(w http.ResponseWriter, book string, aggregate bool) {
+	ob, ok := s.orderBooks[book]
+	if !ok {
+		writeUnknownBook(w, book)
+		return
+	}
+
+	bids, asks := ob.bids, ob.asks
+	if aggregate {
+		bids, asks = aggregateEntries(bids), aggregateEntries(asks)
+	}
+
+	writePayload(w, map[string]any{
+		"bids":       bids,
+		"asks":       asks,
+		"updated_at": time.Now().UTC(),
+		"sequence":   strconv.FormatInt(ob.sequence, 10),
+	})
+}
+
+// aggregateEntries groups the orders by price, keeping their order.
+func aggregateEntries(entries []bitso_client.OrderBookEntry) []bitso_client.OrderBookEntry {
+	levels := []bitso_client.OrderBookEntry{}
+	index := make(map[string]int)
+	for _, e := range entries {
+		key := e.Price.Rat().RatString()
+		if i, ok := index[key]; ok {
+			levels[i].Amount = levels[i].Amount.Add(e.Amount)
+			continue
+		}
+
+		index[key] = len(levels)
+		levels = append(levels, bitso_client.OrderBookEntry{Book: e.Book, Price: e.Price, Amount: e.Amount})
+	}
+
+	return levels
+}
+
+func (s *Server) serveTrades(w http.ResponseWriter, book string, query url.Values) {
+	trades, ok := s.trades[book]
+	if !ok {
+		writeUnknownBook(w, book)
+		return
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 {
+		limit = defaultTradesLimit
+	}
+	limit = min(limit, maxTradesLimit)
+	marker, _ := strconv.ParseInt(query.Get("marker"), 10, 64)
+	asc := query.Get("sort") == string(bitso_client.SortAsc)
+
+	payload := []bitso_client.Trade{}
+	for i := range trades {
+		t := trades[i]
+		if !asc {
+			t = trades[len(trades)-1-i]
+		}
+
+		if marker > 0 && ((asc && t.Tid <= marker) || (!asc && t.Tid >= marker)) {
+			continue
+		}
+
+		payload = append(payload, t)
+		if len(payload) == limit {
+			break
+		}
+	}
+
+	writePayload(w, payload)
+}
+
+// availableBooks returns the scripted books, or every book with data.
+// The caller must hold the mutex.
+func (s *Server) availableBooks() []bitso_client.Book {
+	if s.books != nil {
+		return s.books
+	}
+
+	names := make(map[string]bool)
+	for name := range s.tickers {
+		names[name] = true
+	}
+	for name := range s.orderBooks {
+		names[name] = true
+	}
+	for name := range s.trades {
+		names[name] = true
+	}
+
+	books := make([]bitso_client.Book, 0, len(names))
+	for name := range names {
+		books = append(books, bitso_client.Book{Book: name})
+	}
+	sort.Slice(books, func(i, j int) bool {
+		return books[i].Book < books[j].Book
+	})
+
+	return books
+}
+
+func writePayload(w http.ResponseWriter, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"payload": payload,
+	})
+}
+
+func writeUnknownBook(w http.ResponseWriter, book string) {
+	writeFault(w, Fault{Status: http.StatusBadRequest, Code: "0301", Message: "Unknown OrderBook " + book})
+}
+
+func writeFault(w http.ResponseWriter, f Fault) {
+	if f.Status == 0 {
+		f.Status = http.StatusInternalServerError
+	}
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(f.RetryAfter.Round(time.Second)/time.Second)))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.Status)
+
+	resp := map[string]any{"success": false}
+	if f.Code != "" || f.Message != "" {
+		resp["error"] = bitso_client.BitsoError{Code: f.Code, Message: f.Message}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,149 @@
+package bitsotest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/bitso_client"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+func TestServerTickers(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.SetTicker(bitso_client.BTC_MXN, Ticker{Last: decimal.MustParse("512345.67"), Bid: decimal.MustParse("512300.00")})
+	srv.SetTicker(bitso_client.ETH_MXN, Ticker{Last: decimal.MustParse("62750.80")})
+
+	client := srv.Client()
+	ticker, err := client.GetTicker(bitso_client.BTC_MXN)
+	if err != nil {
+		t.Fatalf("GetTicker() error = %v", err)
+	}
+	if ticker.Payload.Last.String() != "512345.67" || ticker.Payload.Bid.String() != "512300.00" || ticker.Payload.CreatedAt.IsZero() {
+		t.Errorf("GetTicker() = %+v", ticker.Payload)
+	}
+
+	tickers, err := client.GetAllTickers()
+	if err != nil || len(tickers) != 2 || tickers[bitso_client.ETH_MXN].Payload.Last.String() != "62750.80" {
+		t.Errorf("GetAllTickers() = %v, %v", tickers, err)
+	}
+
+	if _, err = client.GetTicker(bitso_client.XRP_USD); !errors.Is(err, bitso_client.ErrUnknownBook) {
+		t.Errorf("GetTicker() of an unknown book error = %v, want %v", err, bitso_client.ErrUnknownBook)
+	}
+
+	// The available books are derived from the scripted data.
+	books, err := client.GetAvailableBooks()
+	if err != nil || len(books) != 2 || books[0].Book != "btc_mxn" {
+		t.Errorf("GetAvailableBooks() = %v, %v", books, err)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 4 || requests[0].Path != "/ticker" || requests[0].Query.Get("book") != "btc_mxn" {
+		t.Errorf("Requests() = %+v", requests)
+	}
+	if srv.RequestCount("/ticker") != 3 {
+		t.Errorf("RequestCount(/ticker) = %d, want 3", srv.RequestCount("/ticker"))
+	}
+}
+
+func TestServerOrderBookAndTrades(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	entry := func(oid, price, amount string) bitso_client.OrderBookEntry {
+		return bitso_client.OrderBookEntry{Book: "btc_mxn", Oid: oid, Price: decimal.MustParse(price), Amount: decimal.MustParse(amount)}
+	}
+	srv.SetOrderBook(bitso_client.BTC_MXN, 42,
+		[]bitso_client.OrderBookEntry{entry("b1", "100.00", "1"), entry("b2", "100.00", "0.25"), entry("b3", "99.50", "2")},
+		[]bitso_client.OrderBookEntry{entry("a1", "101.00", "1")},
+	)
+
+	client := srv.Client()
+	ob, err := client.GetOrderBook(bitso_client.BTC_MXN)
+	if err != nil {
+		t.Fatalf("GetOrderBook() error = %v", err)
+	}
+	if len(ob.Payload.Bids) != 2 || ob.Payload.Bids[0].Amount.String() != "1.25" || ob.Payload.Bids[0].Oid != "" || ob.Payload.Sequence != 42 {
+		t.Errorf("GetOrderBook() = %+v", ob.Payload)
+	}
+
+	ob, err = client.GetUnaggregatedOrderBook(bitso_client.BTC_MXN)
+	if err != nil || len(ob.Payload.Bids) != 3 || ob.Payload.Bids[1].Oid != "b2" {
+		t.Errorf("GetUnaggregatedOrderBook() = %+v, %v", ob.Payload, err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var trades []bitso_client.Trade
+	for tid := int64(1); tid <= 150; tid++ {
+		trades = append(trades, bitso_client.Trade{Book: "btc_mxn", Tid: tid, CreatedAt: start.Add(time.Duration(tid) * time.Minute)})
+	}
+	srv.SetTrades(bitso_client.BTC_MXN, trades)
+
+	var count int
+	it := bitso_client.NewTradeIterator(client, bitso_client.BTC_MXN, start.Add(31*time.Minute))
+	for it.Next() {
+		count++
+	}
+	if it.Err() != nil || count != 120 {
+		t.Errorf("TradeIterator got %d trades, %v, want 120", count, it.Err())
+	}
+
+	page, err := client.GetTradesPage(bitso_client.BTC_MXN, bitso_client.TradesParams{Marker: 10, Sort: bitso_client.SortAsc, Limit: 5})
+	if err != nil || len(page) != 5 || page[0].Tid != 11 {
+		t.Errorf("GetTradesPage() = %v, %v", page, err)
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.SetTicker(bitso_client.BTC_MXN, Ticker{Last: decimal.MustParse("1")})
+	client := srv.Client()
+
+	srv.Fail("/ticker", RateLimited(2*time.Second), 1)
+	_, err := client.GetTicker(bitso_client.BTC_MXN)
+	var apiErr *bitso_client.APIError
+	if !errors.Is(err, bitso_client.ErrRateLimited) || !errors.As(err, &apiErr) || apiErr.RetryAfter != 2*time.Second {
+		t.Errorf("GetTicker() error = %v, want a rate limit with Retry-After", err)
+	}
+
+	// The fault is served once.
+	if _, err = client.GetTicker(bitso_client.BTC_MXN); err != nil {
+		t.Errorf("GetTicker() after the fault error = %v", err)
+	}
+
+	srv.Fail("", ServerError(), 0)
+	for i := 0; i < 2; i++ {
+		if _, err = client.GetAvailableBooks(); !errors.Is(err, bitso_client.ErrUnavailable) {
+			t.Errorf("GetAvailableBooks() #%d error = %v, want %v", i, err, bitso_client.ErrUnavailable)
+		}
+	}
+	srv.ClearFaults()
+
+	srv.Fail("/ticker", Unsuccessful("0301", "Unknown OrderBook"), 1)
+	if _, err = client.GetTicker(bitso_client.BTC_MXN); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || !errors.Is(err, bitso_client.ErrUnknownBook) {
+		t.Errorf("GetTicker() error = %v, want an unsuccessful response", err)
+	}
+
+}
+
+func TestServerLatency(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.SetTicker(bitso_client.BTC_MXN, Ticker{Last: decimal.MustParse("1")})
+	srv.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := srv.Client().GetTickerContext(ctx, bitso_client.BTC_MXN); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetTickerContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,179 @@
+package bitso_client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultBookCatalogTTL is the time the available books are cached for.
+const defaultBookCatalogTTL = time.Hour
+
+// bookCatalogRetryInterval is the time to wait before trying to load the
+// available books again after a failure.
+const bookCatalogRetryInterval = time.Minute
+
+// bookCatalog caches the available books, so book names can be validated
+// and their limits reported without a network round trip.
+type bookCatalog struct {
+	mutex      sync.Mutex
+	ttl        time.Duration
+	books      map[TickerName]Book
+	expiration time.Time
+	lastErr    error
+	call       *bookCatalogCall // call is the load in flight, if any.
+}
+
+// bookCatalogCall represents a load of the available books in flight.
+type bookCatalogCall struct {
+	done  chan struct{} // done is closed once books and err are set.
+	books map[TickerName]Book
+	err   error
+}
+
+func newBookCatalog(ttl time.Duration) *bookCatalog {
+	return &bookCatalog{ttl: ttl}
+}
+
+// get returns the cached books, calling load to refresh them when the cache
+// is expired. If a refresh fails, the stale books are kept (when present)
+// and the next attempt is delayed by bookCatalogRetryInterval.
+// The load runs without holding the lock and is shared by the concurrent
+// callers; a caller whose ctx is done stops waiting for it.
+func (bc *bookCatalog) get(ctx context.Context, load func(ctx context.Context) ([]Book, error)) (map[TickerName]Book, error) {
+	bc.mutex.Lock()
+	if time.Now().Before(bc.expiration) {
+		defer bc.mutex.Unlock()
+		if bc.books == nil {
+			return nil, bc.lastErr
+		}
+		return bc.books, nil
+	}
+
+	call := bc.call
+	if call == nil {
+		call = &bookCatalogCall{done: make(chan struct{})}
+		bc.call = call
+		go bc.load(context.WithoutCancel(ctx), call, load)
+	}
+	bc.mutex.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return call.books, call.err
+}
+
+// load refreshes the books for the waiting callers.
+func (bc *bookCatalog) load(ctx context.Context, call *bookCatalogCall, load func(ctx context.Context) ([]Book, error)) {
+	books, err := load(ctx)
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	defer close(call.done)
+
+	bc.call = nil
+	if err != nil {
+		bc.lastErr = err
+		bc.expiration = time.Now().Add(bookCatalogRetryInterval)
+		call.books, call.err = bc.books, nil
+		if bc.books == nil {
+			call.err = err
+		}
+		return
+	}
+
+	bc.books = make(map[TickerName]Book, len(books))
+	for _, b := range books {
+		bc.books[TickerName(b.Book)] = b
+	}
+	bc.lastErr = nil
+	bc.expiration = time.Now().Add(bc.ttl)
+	call.books = bc.books
+}
+
+// catalog returns the available books, from the cache when it is enabled.
+func (c *bitsoClient) catalog(ctx context.Context) (map[TickerName]Book, error) {
+	if c.books == nil {
+		books, err := c.getAvailableBooks(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		catalog := make(map[TickerName]Book, len(books))
+		for _, b := range books {
+			catalog[TickerName(b.Book)] = b
+		}
+		return catalog, nil
+	}
+
+	return c.books.get(ctx, c.getAvailableBooks)
+}
+
+// checkBook rejects the given book locally if it is not listed in the
+// cached catalog. When the catalog is disabled or can't be loaded (ex. the
+// available books endpoint is down), the check is skipped and the request is
+// sent anyway: the API remains the source of truth, and rejects an unknown
+// book itself. Only a done ctx is returned as an error.
+func (c *bitsoClient) checkBook(ctx context.Context, name TickerName) error {
+	if c.books == nil {
+		return nil
+	}
+
+	catalog, err := c.catalog(ctx)
+	if err != nil {
+		// Don't hide a canceled request behind the skipped check.
+		return ctx.Err()
+	}
+
+	if _, ok := catalog[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownBook, name)
+	}
+
+	return nil
+}
+
+// GetBook retrieves the given book and its limits (minimum and maximum
+// amount, price and value, tick size and fees) from the cached catalog.
+// It returns ErrUnknownBook if the book is not available.
+func (c *bitsoClient) GetBook(ticker TickerName) (Book, error) {
+	return c.GetBookContext(context.Background(), ticker)
+}
+
+// GetBookContext retrieves the given book and its limits from the cached
+// catalog, returning ctx.Err() if ctx is done while the catalog is refreshed.
+func (c *bitsoClient) GetBookContext(ctx context.Context, ticker TickerName) (Book, error) {
+	catalog, err := c.catalog(ctx)
+	if err != nil {
+		return Book{}, fmt.Errorf("failed to load book catalog: %w", err)
+	}
+
+	b, ok := catalog[ticker]
+	if !ok {
+		return Book{}, fmt.Errorf("%w: %s", ErrUnknownBook, ticker)
+	}
+
+	return b, nil
+}
+
+// ValidateBook checks the given book against the cached catalog.
+// It returns ErrUnknownBook if the book is not available.
+// Example:
+//
+//	if err := client.ValidateBook("btc_btc"); errors.Is(err, ErrUnknownBook) {
+//		log.Fatal(err)
+//	}
+func (c *bitsoClient) ValidateBook(ticker TickerName) error {
+	return c.ValidateBookContext(context.Background(), ticker)
+}
+
+// ValidateBookContext checks the given book against the cached catalog,
+// returning ctx.Err() if ctx is done while the catalog is refreshed.
+func (c *bitsoClient) ValidateBookContext(ctx context.Context, ticker TickerName) error {
+	_, err := c.GetBookContext(ctx, ticker)
+	return err
+}
@@ -0,0 +1,192 @@
+package bitso_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+const availableBooksResponse = `{"success": true, "payload": [{
+	"book": "btc_mxn",
+	"minimum_amount": ".003",
+	"maximum_amount": "1000.00",
+	"minimum_price": "100.00",
+	"maximum_price": "1000000.00",
+	"minimum_value": "25.00",
+	"maximum_value": "1000000.00",
+	"tick_size": "0.01",
+	"fees": {
+		"flat_rate": {"maker": "0.500", "taker": "0.650"},
+		"structure": [{"volume": "1500000", "maker": "0.00500", "taker": "0.00650"}]
+	}
+}]}`
+
+// newCatalogServer serves the available books and the ticker endpoints,
+// counting the requests received by each path.
+func newCatalogServer(booksStatus int, requests map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/available_books":
+			w.WriteHeader(booksStatus)
+			_, _ = w.Write([]byte(availableBooksResponse))
+		case "/ticker":
+			_, _ = w.Write([]byte(`{"success": true, "payload": {"book": "btc_mxn", "last": "12345.67"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestGetAvailableBooks(t *testing.T) {
+	requests := map[string]int{}
+	srv := newCatalogServer(http.StatusOK, requests)
+	defer srv.Close()
+
+	client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0))
+	got, err := client.GetAvailableBooks()
+	if err != nil {
+		t.Fatalf("GetAvailableBooks() error = %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("GetAvailableBooks() got %d books, want 1", len(got))
+	}
+
+	b := got[0]
+	if b.Book != "btc_mxn" || b.MinimumAmount.String() != "0.003" || b.MaximumValue.String() != "1000000.00" || b.TickSize.String() != "0.01" {
+		t.Errorf("GetAvailableBooks() got = %+v", b)
+	}
+
+	if b.Fees.FlatRate.Taker.String() != "0.650" || len(b.Fees.Structure) != 1 || b.Fees.Structure[0].Volume.String() != "1500000" {
+		t.Errorf("GetAvailableBooks() fees = %+v", b.Fees)
+	}
+}
+
+func TestBookCatalog(t *testing.T) {
+	tests := []struct {
+		name         string
+		booksStatus  int
+		ticker       TickerName
+		wantErr      error
+		wantRequests map[string]int
+	}{
+		{
+			name:         "known book",
+			booksStatus:  http.StatusOK,
+			ticker:       BTC_MXN,
+			wantRequests: map[string]int{"/available_books": 1, "/ticker": 3},
+		},
+		{
+			name:         "unknown book is rejected locally",
+			booksStatus:  http.StatusOK,
+			ticker:       TickerName("btc_btc"),
+			wantErr:      ErrUnknownBook,
+			wantRequests: map[string]int{"/available_books": 1},
+		},
+		{
+			name:         "catalog unavailable",
+			booksStatus:  http.StatusInternalServerError,
+			ticker:       TickerName("btc_btc"),
+			wantRequests: map[string]int{"/available_books": 1, "/ticker": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := map[string]int{}
+			srv := newCatalogServer(tt.booksStatus, requests)
+			defer srv.Close()
+
+			client := NewClient(WithBaseUrl(srv.URL))
+			for i := 0; i < 3; i++ {
+				_, err := client.GetTicker(tt.ticker)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("GetTicker() error = %v, want %v", err, tt.wantErr)
+				}
+			}
+
+			for path, want := range tt.wantRequests {
+				if requests[path] != want {
+					t.Errorf("%s got %d requests, want %d", path, requests[path], want)
+				}
+			}
+
+			if requests["/ticker"] != tt.wantRequests["/ticker"] {
+				t.Errorf("/ticker got %d requests, want %d", requests["/ticker"], tt.wantRequests["/ticker"])
+			}
+		})
+	}
+}
+
+func TestGetBook(t *testing.T) {
+	requests := map[string]int{}
+	srv := newCatalogServer(http.StatusOK, requests)
+	defer srv.Close()
+
+	client := NewClient(WithBaseUrl(srv.URL))
+	b, err := client.GetBook(BTC_MXN)
+	if err != nil {
+		t.Fatalf("GetBook() error = %v", err)
+	}
+
+	if b.MinimumPrice.String() != "100.00" || b.MaximumPrice.String() != "1000000.00" {
+		t.Errorf("GetBook() got = %+v", b)
+	}
+
+	if got := b.RoundToTick(decimal.MustParse("512345.678"), decimal.RoundFloor); got.String() != "512345.67" {
+		t.Errorf("RoundToTick() = %s, want 512345.67", got)
+	}
+
+	if err = client.ValidateBook(XRP_USD); !errors.Is(err, ErrUnknownBook) {
+		t.Errorf("ValidateBook() error = %v, want %v", err, ErrUnknownBook)
+	}
+
+	if requests["/available_books"] != 1 {
+		t.Errorf("/available_books got %d requests, want 1", requests["/available_books"])
+	}
+}
+
+func TestBookCatalogConcurrentLoads(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		_, _ = w.Write([]byte(availableBooksResponse))
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithBaseUrl(srv.URL))
+	results := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			results <- client.ValidateBook(BTC_MXN)
+		}()
+	}
+
+	// A caller that gives up doesn't wait for the load.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.ValidateBookContext(ctx, BTC_MXN); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ValidateBookContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("ValidateBook() error = %v", err)
+		}
+	}
+
+	// The concurrent lookups shared a single load.
+	if got := requests.Load(); got != 1 {
+		t.Errorf("/available_books got %d requests, want 1", got)
+	}
+}
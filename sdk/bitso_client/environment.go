@@ -0,0 +1,71 @@
+package bitso_client
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrEnvironmentNotAllowed represents an error when a private or trading call
+// is sent to an environment that is not allowed for the signed requests.
+var ErrEnvironmentNotAllowed = errors.New("environment not allowed")
+
+// Environment represents a Bitso API environment: one of Production, Stage
+// or Sandbox, or a Custom URL (ex. an httptest server or a proxy).
+type Environment struct {
+	name    string
+	baseUrl string
+}
+
+var (
+	// Production represents the live exchange, with real funds.
+	Production = Environment{name: "production", baseUrl: "https://bitso.com/api/v3"}
+	// Stage represents the staging environment used to test integrations.
+	Stage = Environment{name: "stage", baseUrl: "https://api-stage.bitso.com/api/v3"}
+	// Sandbox represents the sandbox environment used for dev and testing.
+	Sandbox = Environment{name: "sandbox", baseUrl: "https://api-sandbox.bitso.com/api/v3"}
+)
+
+// defaultSignedEnvironments holds the environments the private and trading
+// calls are sent to by default. Production must be allowed explicitly.
+var defaultSignedEnvironments = []string{Stage.name, Sandbox.name, "custom"}
+
+// Custom creates an environment for the given base URL, which must include
+// the API version path, if any.
+func Custom(baseUrl string) Environment {
+	return Environment{name: "custom", baseUrl: baseUrl}
+}
+
+// Name returns the name of the environment: "production", "stage",
+// "sandbox" or "custom".
+func (e Environment) Name() string {
+	return e.name
+}
+
+// BaseUrl returns the base URL of the API in the environment.
+func (e Environment) BaseUrl() string {
+	return e.baseUrl
+}
+
+func (e Environment) String() string {
+	if e.name == "custom" {
+		return e.name + "(" + e.baseUrl + ")"
+	}
+
+	return e.name
+}
+
+// Environment returns the environment the client sends the requests to.
+func (c *bitsoClient) Environment() Environment {
+	return c.env
+}
+
+// checkSignedEnvironment checks that the private and trading calls can be
+// sent to the environment of the client.
+func (c *bitsoClient) checkSignedEnvironment() error {
+	if !slices.Contains(c.signedEnvironments, c.env.name) {
+		return fmt.Errorf("%w: private calls are not allowed in %s", ErrEnvironmentNotAllowed, c.env)
+	}
+
+	return nil
+}
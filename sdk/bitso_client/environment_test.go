@@ -0,0 +1,102 @@
+package bitso_client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    Environment
+		wantUrl string
+	}{
+		{name: "default", want: Sandbox, wantUrl: "https://api-sandbox.bitso.com/api/v3"},
+		{name: "production", opts: []Option{WithEnvironment(Production)}, want: Production, wantUrl: "https://bitso.com/api/v3"},
+		{name: "stage", opts: []Option{WithEnvironment(Stage)}, want: Stage, wantUrl: "https://api-stage.bitso.com/api/v3"},
+		{name: "production mode", opts: []Option{WithProductionMode(true)}, want: Production, wantUrl: "https://bitso.com/api/v3"},
+		{name: "base url", opts: []Option{WithBaseUrl("http://localhost:8080")}, want: Custom("http://localhost:8080"), wantUrl: "http://localhost:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewClient(tt.opts...).Environment()
+			if got != tt.want || got.BaseUrl() != tt.wantUrl {
+				t.Errorf("Environment() = %s (%s), want %s (%s)", got, got.BaseUrl(), tt.want, tt.wantUrl)
+			}
+		})
+	}
+}
+
+func TestSignedEnvironments(t *testing.T) {
+	var requests []string
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests = append(requests, r.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"success": true, "payload": {"balances": []}}`)),
+		}, nil
+	})
+	credentials := NewStaticCredentials(testApiKey, testApiSecret)
+
+	// Public calls are allowed everywhere.
+	public := NewClient(WithEnvironment(Production), WithBookCatalog(0), WithTransport(transport))
+	if _, err := public.GetTicker(BTC_MXN); err != nil {
+		t.Fatalf("GetTicker() error = %v", err)
+	}
+
+	// Private calls to production are refused before reaching the network.
+	private := NewPrivateClient(credentials, WithEnvironment(Production), WithTransport(transport))
+	if _, err := private.GetBalance(); !errors.Is(err, ErrEnvironmentNotAllowed) {
+		t.Errorf("GetBalance() error = %v, want %v", err, ErrEnvironmentNotAllowed)
+	}
+
+	trading := NewTradingClient(credentials, WithEnvironment(Production), WithTransport(transport))
+	if err := trading.CancelOrder("oid"); !errors.Is(err, ErrEnvironmentNotAllowed) {
+		t.Errorf("CancelOrder() error = %v, want %v", err, ErrEnvironmentNotAllowed)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("got requests %v, want only the ticker", requests)
+	}
+
+	// Unless production is allowed explicitly.
+	private = NewPrivateClient(credentials,
+		WithEnvironment(Production),
+		WithSignedEnvironments(Production),
+		WithTransport(transport),
+	)
+	if _, err := private.GetBalance(); err != nil {
+		t.Errorf("GetBalance() error = %v", err)
+	}
+
+	if len(requests) != 2 || requests[1] != "https://bitso.com/api/v3/balance" {
+		t.Errorf("got requests %v, want the balance in production", requests)
+	}
+
+	// Allowing production only refuses the rest of the environments.
+	private = NewPrivateClient(credentials, WithEnvironment(Sandbox), WithSignedEnvironments(Production))
+	if _, err := private.GetBalance(); !errors.Is(err, ErrEnvironmentNotAllowed) {
+		t.Errorf("GetBalance() error = %v, want %v", err, ErrEnvironmentNotAllowed)
+	}
+
+	// The refused calls don't take a rate limit token, nor wait for one.
+	limiter := NewRateLimiter(map[EndpointClass]RateLimit{PrivateEndpoints: {Requests: 1, Interval: time.Hour}})
+	private = NewPrivateClient(credentials, WithEnvironment(Production), WithTransport(transport), WithRateLimiter(limiter))
+	for i := 0; i < 2; i++ {
+		if _, err := private.GetBalance(); !errors.Is(err, ErrEnvironmentNotAllowed) {
+			t.Errorf("GetBalance() #%d error = %v, want %v", i, err, ErrEnvironmentNotAllowed)
+		}
+	}
+	for _, state := range limiter.State() {
+		if state.Class == PrivateEndpoints && state.Available != 1 {
+			t.Errorf("State() = %+v, want the private token available", state)
+		}
+	}
+}
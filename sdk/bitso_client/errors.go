@@ -0,0 +1,110 @@
+package bitso_client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrUnknownBook represents an error when a book is not listed by the
+	// `/available_books` endpoint.
+	ErrUnknownBook = errors.New("unknown book")
+	// ErrRateLimited represents an error when the API rejects a request
+	// because the request limit was exceeded (HTTP 429).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnavailable represents an error when the API can't be reached or
+	// fails to process a request (HTTP 5xx).
+	ErrUnavailable = errors.New("service unavailable")
+	// ErrInvalidResponse represents an error when the API response can't be decoded.
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+// bitsoErrorCodes maps the Bitso error codes to the errors of this package.
+// The limits of the orders are checked locally before sending them too, but
+// the server may still reject an order whose book limits changed since.
+// ref: https://docs.bitso.com/bitso-api/docs/error-codes
+var bitsoErrorCodes = map[string]error{
+	"0301": ErrUnknownBook,       // Unknown OrderBook.
+	"0322": ErrAmountOutOfRange,  // Amount below the minimum of the book.
+	"0323": ErrAmountOutOfRange,  // Amount above the maximum of the book.
+	"0324": ErrPriceOutOfRange,   // Price below the minimum of the book.
+	"0325": ErrPriceOutOfRange,   // Price above the maximum of the book.
+	"0379": ErrInsufficientFunds, // Insufficient funds.
+	"0404": ErrOrderNotFound,     // Order not found.
+	"0405": ErrOrderNotFound,     // Order already completed or cancelled.
+}
+
+// APIError represents a failed API request: either a response with an HTTP
+// status other than 200, or a response with "success" == false.
+// Code and Message hold the Bitso error, when the response includes it, and
+// RetryAfter holds the time to wait before retrying a rate limited request.
+// Use errors.Is with ErrRateLimited, ErrUnavailable, ErrUnknownBook,
+// ErrInsufficientFunds, ErrOrderNotFound, ErrPriceOutOfRange or
+// ErrAmountOutOfRange to classify it.
+// Example:
+//
+//	_, err := client.GetTicker(BTC_MXN)
+//	var apiErr *APIError
+//	if errors.As(err, &apiErr) {
+//		fmt.Printf("status: %d, code: %s\n", apiErr.StatusCode, apiErr.Code)
+//	}
+//	if errors.Is(err, ErrRateLimited) {
+//		// slow down
+//	}
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("API error (status %d, code %s): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is reports whether the API error matches the given error of this package.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+
+	if kind := e.kind(); kind != nil {
+		return target == kind
+	}
+
+	return target == ErrUnknownBook && e.StatusCode == http.StatusNotFound
+}
+
+// Retryable reports whether the same request may succeed if retried later
+// (rate limits and server errors).
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// kind returns the error of this package that matches the Bitso error code,
+// if any.
+func (e *APIError) kind() error {
+	return bitsoErrorCodes[e.Code]
+}
+
+// Retryable reports whether the request that returned err may succeed if
+// retried later: rate limits, server errors and network failures are
+// retryable, while API rejections, invalid responses and canceled requests
+// are not.
+func Retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrRateLimited)
+}
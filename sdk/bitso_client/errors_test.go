@@ -0,0 +1,147 @@
+package bitso_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorClassification(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantIs        []error
+		wantNotIs     []error
+		wantRetryable bool
+	}{
+		{
+			name:          "rate limited",
+			status:        http.StatusTooManyRequests,
+			body:          `{"success":false,"error":{"code":"0201","message":"Too many requests"}}`,
+			wantIs:        []error{ErrRateLimited},
+			wantNotIs:     []error{ErrUnavailable, ErrUnknownBook, ErrInvalidResponse},
+			wantRetryable: true,
+		},
+		{
+			name:          "server error",
+			status:        http.StatusServiceUnavailable,
+			body:          `<html>maintenance</html>`,
+			wantIs:        []error{ErrUnavailable},
+			wantNotIs:     []error{ErrRateLimited, ErrUnknownBook},
+			wantRetryable: true,
+		},
+		{
+			name:          "unknown book code",
+			status:        http.StatusBadRequest,
+			body:          `{"success":false,"error":{"code":"0301","message":"Unknown OrderBook btc_btc"}}`,
+			wantIs:        []error{ErrUnknownBook},
+			wantNotIs:     []error{ErrRateLimited, ErrUnavailable},
+			wantRetryable: false,
+		},
+		{
+			name:          "not found",
+			status:        http.StatusNotFound,
+			body:          ``,
+			wantIs:        []error{ErrUnknownBook},
+			wantNotIs:     []error{ErrUnavailable},
+			wantRetryable: false,
+		},
+		{
+			name:          "unsuccessful response",
+			status:        http.StatusOK,
+			body:          `{"success":false,"error":{"code":"0101","message":"Unknown error"}}`,
+			wantNotIs:     []error{ErrRateLimited, ErrUnavailable, ErrUnknownBook, ErrInvalidResponse},
+			wantRetryable: false,
+		},
+		{
+			name:          "invalid response",
+			status:        http.StatusOK,
+			body:          `{"success":`,
+			wantIs:        []error{ErrInvalidResponse},
+			wantNotIs:     []error{ErrUnavailable},
+			wantRetryable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0))
+
+			_, err := client.GetTicker(BTC_MXN)
+			if err == nil {
+				t.Fatal("GetTicker() expected an error")
+			}
+
+			for _, target := range tt.wantIs {
+				if !errors.Is(err, target) {
+					t.Errorf("errors.Is(%v, %v) = false, want true", err, target)
+				}
+			}
+			for _, target := range tt.wantNotIs {
+				if errors.Is(err, target) {
+					t.Errorf("errors.Is(%v, %v) = true, want false", err, target)
+				}
+			}
+
+			if got := Retryable(err); got != tt.wantRetryable {
+				t.Errorf("Retryable(%v) = %v, want %v", err, got, tt.wantRetryable)
+			}
+
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestAPIErrorFields(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"success":false,"error":{"code":"0301","message":"Unknown OrderBook btc_btc"}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0))
+
+	_, err := client.GetTicker(TickerName("btc_btc"))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetTicker() error = %v, want *APIError", err)
+	}
+
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "0301" || apiErr.Message != "Unknown OrderBook btc_btc" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+}
+
+func TestRetryableNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	client := NewClient(WithBaseUrl(url), WithBookCatalog(0))
+
+	_, err := client.GetTicker(BTC_MXN)
+	if !errors.Is(err, ErrUnavailable) || !Retryable(err) {
+		t.Errorf("GetTicker() error = %v, want a retryable ErrUnavailable", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.GetTickerContext(ctx, BTC_MXN)
+	if !errors.Is(err, context.Canceled) || Retryable(err) {
+		t.Errorf("GetTickerContext() error = %v, want a non-retryable context.Canceled", err)
+	}
+}
@@ -0,0 +1,404 @@
+package bitso_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// ErrSequenceGap represents an error when a diff-orders message doesn't
+// follow the sequence of the local order book, so it must be resynced.
+var ErrSequenceGap = errors.New("sequence gap")
+
+// liveOrderBookResyncBackoff is the time to wait before retrying a failed resync.
+const liveOrderBookResyncBackoff = time.Second
+
+// liveOrderBookMaxPending is the number of messages kept while the book is
+// out of sync. When it is exceeded, the messages are dropped and the book is
+// resynced from scratch, as the pending ones could not fill the gap anyway.
+const liveOrderBookMaxPending = 1024
+
+// PriceLevel represents the orders of one side of the book at a price.
+type PriceLevel struct {
+	Price  decimal.Decimal `json:"price"`
+	Amount decimal.Decimal `json:"amount"`
+	Orders int             `json:"orders"`
+}
+
+// LiveOrderBookSnapshot represents a consistent copy of the local order book.
+// Bids are sorted by price descending and asks by price ascending.
+type LiveOrderBookSnapshot struct {
+	Book      string       `json:"book"`
+	Sequence  int64        `json:"sequence"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Bids      []PriceLevel `json:"bids"`
+	Asks      []PriceLevel `json:"asks"`
+}
+
+type liveOrder struct {
+	key    string // key is the normalized price of the level of the order.
+	amount decimal.Decimal
+}
+
+// liveLevel represents the orders of one side of the book at a price.
+type liveLevel struct {
+	key    string
+	price  decimal.Decimal
+	amount decimal.Decimal
+	orders map[string]decimal.Decimal
+}
+
+// bookSide represents one side of the local order book. The orders are
+// aggregated by price as they are set, so reading the best levels doesn't
+// go through every order.
+type bookSide struct {
+	descending bool
+	orders     map[string]liveOrder
+	levels     map[string]*liveLevel
+	sorted     []*liveLevel // sorted keeps the levels sorted from the best price.
+}
+
+func newBookSide(descending bool, size int) *bookSide {
+	return &bookSide{
+		descending: descending,
+		orders:     make(map[string]liveOrder, size),
+		levels:     make(map[string]*liveLevel),
+	}
+}
+
+// LiveOrderBook represents an order book maintained locally from a REST
+// snapshot plus the sequenced messages of the diff-orders channel.
+// When a sequence gap is detected (ex. after a reconnection), the book is
+// marked as out of sync and reloaded from a new snapshot.
+// All the methods are safe for concurrent use.
+// Example:
+//
+//	lob := NewLiveOrderBook(client, BTC_MXN)
+//	go func() {
+//		_ = lob.Run(ctx)
+//	}()
+//
+//	if bid, ok := lob.BestBid(); ok {
+//		fmt.Printf("Best bid: %s (%s)\n", bid.Price, bid.Amount)
+//	}
+type LiveOrderBook struct {
+	client Client
+	book   TickerName
+	resync chan struct{}
+
+	mutex     sync.RWMutex
+	bids      *bookSide
+	asks      *bookSide
+	sequence  int64
+	updatedAt time.Time
+	synced    bool
+	pending   []DiffOrdersMessage // pending keeps the messages received while out of sync.
+}
+
+// NewLiveOrderBook creates a local order book of the given book, which is
+// loaded using the unaggregated order book of the client.
+func NewLiveOrderBook(client Client, book TickerName) *LiveOrderBook {
+	return &LiveOrderBook{
+		client: client,
+		book:   book,
+		resync: make(chan struct{}, 1),
+		bids:   newBookSide(true, 0),
+		asks:   newBookSide(false, 0),
+	}
+}
+
+// Run subscribes to the diff-orders channel of the book and keeps the local
+// order book up to date until ctx is done, resyncing it whenever a sequence
+// gap is detected or the stream reconnects.
+// It always returns a non-nil error: ctx.Err() once ctx is done.
+func (l *LiveOrderBook) Run(ctx context.Context, opts ...StreamOption) error {
+	stream := NewStream(StreamHandler{
+		OnDiffOrders: l.HandleDiffOrders,
+		OnConnect:    l.Invalidate,
+	}, opts...)
+	stream.Subscribe(l.book, DiffOrdersChannel)
+
+	go func() {
+		_ = stream.Run(ctx)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.resync:
+		}
+
+		for l.Sync(ctx) != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(liveOrderBookResyncBackoff):
+			}
+		}
+	}
+}
+
+// Sync loads a snapshot of the order book and applies the diff-orders
+// messages received since the book went out of sync.
+// It returns ErrSequenceGap if the pending messages don't follow the
+// snapshot, in which case Sync must be called again.
+func (l *LiveOrderBook) Sync(ctx context.Context) error {
+	// Keep the messages received while loading the snapshot.
+	l.mutex.Lock()
+	l.synced = false
+	l.mutex.Unlock()
+
+	ob, err := l.client.GetUnaggregatedOrderBookContext(ctx, l.book)
+	if err != nil {
+		return fmt.Errorf("failed to load order book snapshot: %w", err)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.bids = newBookSide(true, len(ob.Payload.Bids))
+	l.asks = newBookSide(false, len(ob.Payload.Asks))
+	for _, e := range ob.Payload.Bids {
+		l.bids.set(e.Oid, e.Price, e.Amount)
+	}
+	for _, e := range ob.Payload.Asks {
+		l.asks.set(e.Oid, e.Price, e.Amount)
+	}
+	l.sequence = ob.Payload.Sequence
+	l.updatedAt = ob.Payload.UpdatedAt
+	l.synced = true
+
+	pending := l.pending
+	l.pending = nil
+	for i, msg := range pending {
+		if err = l.apply(msg); err != nil {
+			// Keep the rest of the messages for the next snapshot.
+			l.pending = append(l.pending, pending[i+1:]...)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HandleDiffOrders applies a message of the diff-orders channel to the
+// local order book. It can be used as the OnDiffOrders callback of a Stream
+// when the book isn't maintained by Run.
+// While the book is out of sync, up to liveOrderBookMaxPending messages are
+// kept; past that, they are dropped and a new resync is requested.
+func (l *LiveOrderBook) HandleDiffOrders(msg DiffOrdersMessage) {
+	if msg.Book != string(l.book) {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.synced {
+		if len(l.pending) >= liveOrderBookMaxPending {
+			l.pending = nil
+			l.invalidate()
+		}
+		l.pending = append(l.pending, msg)
+		return
+	}
+
+	_ = l.apply(msg)
+}
+
+// Invalidate marks the local order book as out of sync, so it is reloaded
+// from a new snapshot.
+func (l *LiveOrderBook) Invalidate() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.invalidate()
+}
+
+// Synced reports whether the local order book is in sync with the server.
+func (l *LiveOrderBook) Synced() bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return l.synced
+}
+
+// BestBid returns the highest bid price level, if any.
+func (l *LiveOrderBook) BestBid() (PriceLevel, bool) {
+	bids, _ := l.Depth(1)
+	if len(bids) == 0 {
+		return PriceLevel{}, false
+	}
+
+	return bids[0], true
+}
+
+// BestAsk returns the lowest ask price level, if any.
+func (l *LiveOrderBook) BestAsk() (PriceLevel, bool) {
+	_, asks := l.Depth(1)
+	if len(asks) == 0 {
+		return PriceLevel{}, false
+	}
+
+	return asks[0], true
+}
+
+// Depth returns up to n price levels of each side of the book.
+// A value of n <= 0 returns every level.
+func (l *LiveOrderBook) Depth(n int) (bids []PriceLevel, asks []PriceLevel) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return l.bids.depth(n), l.asks.depth(n)
+}
+
+// Snapshot returns a consistent copy of every price level of the book.
+func (l *LiveOrderBook) Snapshot() LiveOrderBookSnapshot {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return LiveOrderBookSnapshot{
+		Book:      string(l.book),
+		Sequence:  l.sequence,
+		UpdatedAt: l.updatedAt,
+		Bids:      l.bids.depth(0),
+		Asks:      l.asks.depth(0),
+	}
+}
+
+// apply applies a message to the synced book, invalidating it on a
+// sequence gap. The caller must hold the write lock.
+func (l *LiveOrderBook) apply(msg DiffOrdersMessage) error {
+	if msg.Sequence <= l.sequence {
+		// Already included in the snapshot.
+		return nil
+	}
+
+	if msg.Sequence != l.sequence+1 {
+		l.invalidate()
+		l.pending = append(l.pending, msg)
+		return fmt.Errorf("%w: got %d after %d", ErrSequenceGap, msg.Sequence, l.sequence)
+	}
+
+	for _, o := range msg.Payload {
+		side := l.bids
+		if o.Side == 1 {
+			side = l.asks
+		}
+
+		if o.Status == "open" {
+			side.set(o.Oid, o.Rate, o.Amount)
+		} else {
+			side.remove(o.Oid)
+		}
+
+		if o.Timestamp > 0 {
+			l.updatedAt = time.UnixMilli(o.Timestamp)
+		}
+	}
+	l.sequence = msg.Sequence
+
+	return nil
+}
+
+// invalidate marks the book as out of sync and requests a resync.
+// The caller must hold the write lock.
+func (l *LiveOrderBook) invalidate() {
+	l.synced = false
+	select {
+	case l.resync <- struct{}{}:
+	default:
+	}
+}
+
+// set upserts an order, removing it when its amount is empty or zero.
+func (b *bookSide) set(oid string, price, amount decimal.Decimal) {
+	b.remove(oid)
+	if amount.Sign() <= 0 {
+		return
+	}
+
+	key := priceKey(price)
+	lvl, ok := b.levels[key]
+	if !ok {
+		lvl = &liveLevel{key: key, price: price, orders: make(map[string]decimal.Decimal)}
+		b.levels[key] = lvl
+		i := b.search(price)
+		b.sorted = append(b.sorted, nil)
+		copy(b.sorted[i+1:], b.sorted[i:])
+		b.sorted[i] = lvl
+	}
+
+	lvl.orders[oid] = amount
+	lvl.amount = lvl.amount.Add(amount)
+	b.orders[oid] = liveOrder{key: key, amount: amount}
+}
+
+// remove removes an order, and its level once it has no orders left.
+func (b *bookSide) remove(oid string) {
+	o, ok := b.orders[oid]
+	if !ok {
+		return
+	}
+	delete(b.orders, oid)
+
+	lvl := b.levels[o.key]
+	delete(lvl.orders, oid)
+	if len(lvl.orders) > 0 {
+		// The amount is summed again, rather than subtracted, so it keeps
+		// the decimals of the remaining orders.
+		lvl.amount = decimal.Decimal{}
+		for _, amount := range lvl.orders {
+			lvl.amount = lvl.amount.Add(amount)
+		}
+		return
+	}
+
+	delete(b.levels, o.key)
+	i := b.search(lvl.price)
+	b.sorted = append(b.sorted[:i], b.sorted[i+1:]...)
+}
+
+// search returns the index of the first level whose price isn't better than
+// the given one.
+func (b *bookSide) search(price decimal.Decimal) int {
+	return sort.Search(len(b.sorted), func(i int) bool {
+		if b.descending {
+			return b.sorted[i].price.Cmp(price) <= 0
+		}
+		return b.sorted[i].price.Cmp(price) >= 0
+	})
+}
+
+// depth returns up to n levels, sorted from the best price.
+// A value of n <= 0 returns every level.
+func (b *bookSide) depth(n int) []PriceLevel {
+	if n <= 0 || n > len(b.sorted) {
+		n = len(b.sorted)
+	}
+
+	levels := make([]PriceLevel, n)
+	for i, lvl := range b.sorted[:n] {
+		levels[i] = PriceLevel{Price: lvl.price, Amount: lvl.amount, Orders: len(lvl.orders)}
+	}
+
+	return levels
+}
+
+// priceKey returns the price without trailing zeros, so the prices are
+// grouped by value, regardless of their decimals.
+func priceKey(price decimal.Decimal) string {
+	key := price.String()
+	if strings.Contains(key, ".") {
+		key = strings.TrimRight(strings.TrimRight(key, "0"), ".")
+	}
+
+	return key
+}
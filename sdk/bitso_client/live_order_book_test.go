@@ -0,0 +1,260 @@
+package bitso_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// newSnapshotServer serves the unaggregated order book, returning the
+// snapshots in order (the last one is repeated).
+func newSnapshotServer(snapshots ...string) (*httptest.Server, *int) {
+	var mutex sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		i := min(requests, len(snapshots)-1)
+		requests++
+		_, _ = w.Write([]byte(snapshots[i]))
+	}))
+
+	return srv, &requests
+}
+
+func snapshotResponse(sequence int64, bids, asks string) string {
+	return fmt.Sprintf(`{"success": true, "payload": {"bids": [%s], "asks": [%s],
+		"updated_at": "2024-01-01T00:00:00+00:00", "sequence": "%d"}}`, bids, asks, sequence)
+}
+
+func TestLiveOrderBook(t *testing.T) {
+	srv, requests := newSnapshotServer(snapshotResponse(10,
+		`{"book": "btc_mxn", "price": "100.00", "amount": "1.5", "oid": "b1"},
+		 {"book": "btc_mxn", "price": "100.00", "amount": "0.25", "oid": "b2"},
+		 {"book": "btc_mxn", "price": "99.50", "amount": "2", "oid": "b3"}`,
+		`{"book": "btc_mxn", "price": "101.00", "amount": "1", "oid": "a1"},
+		 {"book": "btc_mxn", "price": "102.00", "amount": "3", "oid": "a2"}`))
+	defer srv.Close()
+
+	lob := NewLiveOrderBook(NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0)), BTC_MXN)
+
+	// Messages received before the snapshot is loaded are kept, and the
+	// ones already included in it are skipped.
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: 10, Payload: []StreamOrder{
+		{Oid: "b1", Rate: decimal.MustParse("100.00"), Side: 0, Status: "cancelled"},
+	}})
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: 11, Payload: []StreamOrder{
+		{Oid: "a3", Rate: decimal.MustParse("100.50"), Amount: decimal.MustParse("0.5"), Side: 1, Status: "open"},
+	}})
+
+	if lob.Synced() {
+		t.Fatalf("Synced() = true before loading the snapshot")
+	}
+
+	if err := lob.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	bid, ok := lob.BestBid()
+	if !ok || bid.Price.String() != "100.00" || bid.Amount.String() != "1.75" || bid.Orders != 2 {
+		t.Errorf("BestBid() = %+v, %v", bid, ok)
+	}
+
+	ask, ok := lob.BestAsk()
+	if !ok || ask.Price.String() != "100.50" || ask.Amount.String() != "0.5" {
+		t.Errorf("BestAsk() = %+v, %v", ask, ok)
+	}
+
+	// Partial fill, cancellation and a message of another book.
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: 12, Payload: []StreamOrder{
+		{Oid: "b1", Rate: decimal.MustParse("100.00"), Amount: decimal.MustParse("1"), Side: 0, Status: "open"},
+		{Oid: "a3", Rate: decimal.MustParse("100.50"), Side: 1, Status: "completed"},
+	}})
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "eth_mxn", Sequence: 99})
+
+	bids, asks := lob.Depth(5)
+	wantBids := []PriceLevel{{Price: decimal.MustParse("100.00"), Amount: decimal.MustParse("1.25"), Orders: 2}, {Price: decimal.MustParse("99.50"), Amount: decimal.MustParse("2"), Orders: 1}}
+	wantAsks := []PriceLevel{{Price: decimal.MustParse("101.00"), Amount: decimal.MustParse("1"), Orders: 1}, {Price: decimal.MustParse("102.00"), Amount: decimal.MustParse("3"), Orders: 1}}
+	if fmt.Sprint(bids) != fmt.Sprint(wantBids) || fmt.Sprint(asks) != fmt.Sprint(wantAsks) {
+		t.Errorf("Depth() = %v, %v, want %v, %v", bids, asks, wantBids, wantAsks)
+	}
+
+	snapshot := lob.Snapshot()
+	if snapshot.Sequence != 12 || len(snapshot.Bids) != 2 || len(snapshot.Asks) != 2 {
+		t.Errorf("Snapshot() = %+v", snapshot)
+	}
+
+	if *requests != 1 {
+		t.Errorf("got %d snapshot requests, want 1", *requests)
+	}
+}
+
+func TestLiveOrderBookSequenceGap(t *testing.T) {
+	srv, requests := newSnapshotServer(
+		snapshotResponse(10, `{"book": "btc_mxn", "price": "100", "amount": "1", "oid": "b1"}`, ``),
+		snapshotResponse(13, `{"book": "btc_mxn", "price": "105", "amount": "1", "oid": "b4"}`, ``),
+	)
+	defer srv.Close()
+
+	lob := NewLiveOrderBook(NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0)), BTC_MXN)
+	if err := lob.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	// Sequence 11 is lost.
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: 12})
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: 14, Payload: []StreamOrder{
+		{Oid: "a1", Rate: decimal.MustParse("106"), Amount: decimal.MustParse("2"), Side: 1, Status: "open"},
+	}})
+	if lob.Synced() {
+		t.Fatalf("Synced() = true after a sequence gap")
+	}
+
+	if err := lob.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if bid, _ := lob.BestBid(); bid.Price.String() != "105" {
+		t.Errorf("BestBid() = %+v, want 105", bid)
+	}
+
+	if ask, _ := lob.BestAsk(); ask.Price.String() != "106" {
+		t.Errorf("BestAsk() = %+v, want 106", ask)
+	}
+
+	if *requests != 2 {
+		t.Errorf("got %d snapshot requests, want 2", *requests)
+	}
+}
+
+func TestLiveOrderBookPendingGap(t *testing.T) {
+	srv, _ := newSnapshotServer(snapshotResponse(10, ``, ``))
+	defer srv.Close()
+
+	lob := NewLiveOrderBook(NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0)), BTC_MXN)
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: 12})
+
+	if err := lob.Sync(context.Background()); !errors.Is(err, ErrSequenceGap) {
+		t.Errorf("Sync() error = %v, want %v", err, ErrSequenceGap)
+	}
+}
+
+func TestLiveOrderBookPendingOverflow(t *testing.T) {
+	srv, requests := newSnapshotServer(snapshotResponse(10, ``, ``))
+	defer srv.Close()
+
+	lob := NewLiveOrderBook(NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0)), BTC_MXN)
+	for seq := int64(11); seq <= 11+liveOrderBookMaxPending; seq++ {
+		lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: seq})
+	}
+
+	// The buffer was dropped once full, so only the last message is left,
+	// which no longer follows the snapshot.
+	lob.mutex.RLock()
+	pending := len(lob.pending)
+	lob.mutex.RUnlock()
+	if pending != 1 {
+		t.Errorf("got %d pending messages, want 1", pending)
+	}
+
+	select {
+	case <-lob.resync:
+	default:
+		t.Errorf("no resync requested after the overflow")
+	}
+
+	if err := lob.Sync(context.Background()); !errors.Is(err, ErrSequenceGap) {
+		t.Errorf("Sync() error = %v, want %v", err, ErrSequenceGap)
+	}
+	if *requests != 1 {
+		t.Errorf("got %d snapshot requests, want 1", *requests)
+	}
+}
+
+func TestLiveOrderBookLevels(t *testing.T) {
+	srv, _ := newSnapshotServer(snapshotResponse(10,
+		`{"book": "btc_mxn", "price": "100.00", "amount": "1.5", "oid": "b1"},
+		 {"book": "btc_mxn", "price": "100", "amount": "0.25", "oid": "b2"}`, ``))
+	defer srv.Close()
+
+	lob := NewLiveOrderBook(NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0)), BTC_MXN)
+	if err := lob.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	// The prices are grouped by value, regardless of their decimals.
+	if bid, _ := lob.BestBid(); bid.Amount.String() != "1.75" || bid.Orders != 2 {
+		t.Errorf("BestBid() = %+v, want 1.75 in 2 orders", bid)
+	}
+
+	// An order moved to a better price opens a new level, and its old
+	// level keeps the rest of the orders.
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: 11, Payload: []StreamOrder{
+		{Oid: "b2", Rate: decimal.MustParse("100.5"), Amount: decimal.MustParse("0.25"), Side: 0, Status: "open"},
+		{Oid: "a1", Rate: decimal.MustParse("102"), Amount: decimal.MustParse("1"), Side: 1, Status: "open"},
+		{Oid: "a2", Rate: decimal.MustParse("101"), Amount: decimal.MustParse("2"), Side: 1, Status: "open"},
+	}})
+
+	bids, asks := lob.Depth(0)
+	if fmt.Sprint(bids) != "[{100.5 0.25 1} {100.00 1.5 1}]" || fmt.Sprint(asks) != "[{101 2 1} {102 1 1}]" {
+		t.Errorf("Depth() = %v, %v", bids, asks)
+	}
+
+	// The emptied levels are removed.
+	lob.HandleDiffOrders(DiffOrdersMessage{Book: "btc_mxn", Sequence: 12, Payload: []StreamOrder{
+		{Oid: "b2", Side: 0, Status: "cancelled"},
+		{Oid: "a2", Side: 1, Status: "completed"},
+	}})
+
+	bids, asks = lob.Depth(1)
+	if fmt.Sprint(bids) != "[{100.00 1.5 1}]" || fmt.Sprint(asks) != "[{102 1 1}]" {
+		t.Errorf("Depth(1) = %v, %v", bids, asks)
+	}
+}
+
+func TestLiveOrderBookRun(t *testing.T) {
+	srv, _ := newSnapshotServer(snapshotResponse(2733,
+		`{"book": "btc_mxn", "price": "628000.00", "amount": "0.1", "oid": "b1"}`, ``))
+	defer srv.Close()
+
+	subscribed := make(chan string, 10)
+	wsSrv := newStreamServer(t, loadStreamFrames(t), 1, subscribed)
+	defer wsSrv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lob := NewLiveOrderBook(NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0)), BTC_MXN)
+	go func() {
+		_ = lob.Run(ctx,
+			WithStreamUrl("ws"+strings.TrimPrefix(wsSrv.URL, "http")),
+			WithStreamBackoff(time.Hour, time.Hour),
+		)
+	}()
+
+	if got := <-subscribed; got != "btc_mxn:diff-orders" {
+		t.Errorf("subscription = %s, want btc_mxn:diff-orders", got)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for lob.Snapshot().Sequence != 2735 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Snapshot() = %+v, want sequence 2735", lob.Snapshot())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The order opened by 2734 was cancelled by 2735.
+	bids, _ := lob.Depth(0)
+	if len(bids) != 1 || bids[0].Price.String() != "628000.00" {
+		t.Errorf("Depth() bids = %v", bids)
+	}
+}
@@ -0,0 +1,188 @@
+package bitso_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// ErrInvalidTimeBucket represents an error when the time bucket of the
+// candles is not supported by the API.
+var ErrInvalidTimeBucket = errors.New("invalid time bucket")
+
+// TimeBucket represents the time period covered by each candle.
+type TimeBucket time.Duration
+
+const (
+	Bucket1m  = TimeBucket(time.Minute)        // Bucket1m for 1 minute candles.
+	Bucket5m  = TimeBucket(5 * time.Minute)    // Bucket5m for 5 minutes candles.
+	Bucket15m = TimeBucket(15 * time.Minute)   // Bucket15m for 15 minutes candles.
+	Bucket30m = TimeBucket(30 * time.Minute)   // Bucket30m for 30 minutes candles.
+	Bucket1h  = TimeBucket(time.Hour)          // Bucket1h for 1 hour candles.
+	Bucket4h  = TimeBucket(4 * time.Hour)      // Bucket4h for 4 hours candles.
+	Bucket1d  = TimeBucket(24 * time.Hour)     // Bucket1d for 1 day candles.
+	Bucket1w  = TimeBucket(7 * 24 * time.Hour) // Bucket1w for 1 week candles.
+)
+
+// valid reports whether the time bucket is supported by the API.
+func (b TimeBucket) valid() bool {
+	switch b {
+	case Bucket1m, Bucket5m, Bucket15m, Bucket30m, Bucket1h, Bucket4h, Bucket1d, Bucket1w:
+		return true
+	}
+
+	return false
+}
+
+func (b TimeBucket) String() string {
+	return time.Duration(b).String()
+}
+
+// maxCandlesPerRequest is the number of candles requested at once; larger
+// ranges are split into several requests.
+const maxCandlesPerRequest = 1000
+
+// Candle represents the trades of a book within a time bucket.
+// Open and Close are the prices of the first and last trades of the bucket.
+type Candle struct {
+	Book        string          `json:"book"`
+	BucketStart time.Time       `json:"bucket_start"`
+	Open        decimal.Decimal `json:"open"`
+	High        decimal.Decimal `json:"high"`
+	Low         decimal.Decimal `json:"low"`
+	Close       decimal.Decimal `json:"close"`
+	Volume      decimal.Decimal `json:"volume"`
+	Vwap        decimal.Decimal `json:"vwap"`
+	Trades      int             `json:"trades"`
+}
+
+// bitsoCandle represents a candle as returned by the API, with the times
+// as unix timestamps in milliseconds.
+type bitsoCandle struct {
+	BucketStartTime int64           `json:"bucket_start_time"`
+	FirstRate       decimal.Decimal `json:"first_rate"`
+	LastRate        decimal.Decimal `json:"last_rate"`
+	MinRate         decimal.Decimal `json:"min_rate"`
+	MaxRate         decimal.Decimal `json:"max_rate"`
+	TradeCount      int             `json:"trade_count"`
+	Volume          decimal.Decimal `json:"volume"`
+	Vwap            decimal.Decimal `json:"vwap"`
+}
+
+type bitsoOHLCResponse struct {
+	bitsoBaseResponse
+	Payload []bitsoCandle `json:"payload"`
+}
+
+// getOHLC calls `<bitsoBaseUrl>/ohlc?book=<name>&time_bucket=<seconds>&start=<ms>&end=<ms>`
+// once per chunk of at most maxCandlesPerRequest candles, and returns the
+// candles of the [start, end) range sorted by bucket start.
+// Otherwise, it returns an error.
+// Example response:
+//
+//	{
+//		"success": true,
+//		"payload": [{
+//			"bucket_start_time": 1680480000000,
+//			"first_trade_time": 1680480000120,
+//			"last_trade_time": 1680480059880,
+//			"first_rate": "512345.67",
+//			"last_rate": "512400.00",
+//			"min_rate": "512300.00",
+//			"max_rate": "512450.10",
+//			"trade_count": 42,
+//			"volume": "0.52318234",
+//			"vwap": "512381.20"
+//		}]
+//	}
+func (c *bitsoClient) getOHLC(ctx context.Context, name TickerName, bucket TimeBucket, start, end time.Time) ([]Candle, error) {
+	if !bucket.valid() {
+		return nil, fmt.Errorf("failed to get OHLC: %w: %s", ErrInvalidTimeBucket, bucket)
+	}
+
+	if !end.After(start) {
+		return nil, nil
+	}
+
+	if err := c.checkBook(ctx, name); err != nil {
+		return nil, fmt.Errorf("failed to get OHLC: %w", err)
+	}
+
+	// Candles are deduplicated by bucket start, since the chunks may overlap
+	// at their edges.
+	byStart := make(map[int64]Candle)
+	chunk := time.Duration(bucket) * maxCandlesPerRequest
+	for from := start; from.Before(end); from = from.Add(chunk) {
+		to := from.Add(chunk)
+		if to.After(end) {
+			to = end
+		}
+
+		params := url.Values{}
+		params.Set("book", string(name))
+		params.Set("time_bucket", fmt.Sprint(int64(time.Duration(bucket)/time.Second)))
+		params.Set("start", fmt.Sprint(from.UnixMilli()))
+		params.Set("end", fmt.Sprint(to.UnixMilli()))
+
+		var resp bitsoOHLCResponse
+		if err := c.get(ctx, "/ohlc", params, &resp); err != nil {
+			return nil, fmt.Errorf("failed to get OHLC: %w", err)
+		}
+
+		for _, bc := range resp.Payload {
+			bucketStart := time.UnixMilli(bc.BucketStartTime)
+			if bucketStart.Before(start) || !bucketStart.Before(end) {
+				continue
+			}
+
+			byStart[bc.BucketStartTime] = Candle{
+				Book:        string(name),
+				BucketStart: bucketStart.UTC(),
+				Open:        bc.FirstRate,
+				High:        bc.MaxRate,
+				Low:         bc.MinRate,
+				Close:       bc.LastRate,
+				Volume:      bc.Volume,
+				Vwap:        bc.Vwap,
+				Trades:      bc.TradeCount,
+			}
+		}
+	}
+
+	candles := make([]Candle, 0, len(byStart))
+	for _, candle := range byStart {
+		candles = append(candles, candle)
+	}
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].BucketStart.Before(candles[j].BucketStart)
+	})
+
+	return candles, nil
+}
+
+// GetOHLC retrieves the candles of the given book for the time buckets
+// starting within [start, end), oldest first. Large ranges are fetched with
+// several requests. Buckets without trades are not included.
+// Example:
+//
+//	candles, err := client.GetOHLC(BTC_MXN, Bucket1h, time.Now().Add(-24*time.Hour), time.Now())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, candle := range candles {
+//		fmt.Printf("%s: %s - %s\n", candle.BucketStart, candle.Open, candle.Close)
+//	}
+func (c *bitsoClient) GetOHLC(ticker TickerName, bucket TimeBucket, start, end time.Time) ([]Candle, error) {
+	return c.GetOHLCContext(context.Background(), ticker, bucket, start, end)
+}
+
+// GetOHLCContext retrieves the candles like GetOHLC, canceling the requests
+// when ctx is done.
+func (c *bitsoClient) GetOHLCContext(ctx context.Context, ticker TickerName, bucket TimeBucket, start, end time.Time) ([]Candle, error) {
+	return c.getOHLC(ctx, ticker, bucket, start, end)
+}
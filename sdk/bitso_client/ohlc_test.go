@@ -0,0 +1,107 @@
+package bitso_client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+// newOHLCServer serves one candle per bucket of the requested range,
+// including the bucket starting at the end of the range, as the Bitso API
+// does.
+func newOHLCServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		q := r.URL.Query()
+		if r.URL.Path != "/ohlc" || q.Get("book") != string(BTC_MXN) {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		bucket, _ := strconv.ParseInt(q.Get("time_bucket"), 10, 64)
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+
+		payload := []bitsoCandle{}
+		for ms := start; ms <= end; ms += bucket * 1000 {
+			price := decimal.New(ms/1000, 2)
+			payload = append(payload, bitsoCandle{
+				BucketStartTime: ms,
+				FirstRate:       price,
+				LastRate:        price.Add(decimal.NewFromInt(1)),
+				MinRate:         price,
+				MaxRate:         price.Add(decimal.NewFromInt(2)),
+				TradeCount:      3,
+				Volume:          decimal.MustParse("0.5"),
+				Vwap:            price,
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(bitsoOHLCResponse{
+			bitsoBaseResponse: bitsoBaseResponse{Success: true},
+			Payload:           payload,
+		})
+	}))
+}
+
+func TestGetOHLC(t *testing.T) {
+	var requests int
+	srv := newOHLCServer(t, &requests)
+	defer srv.Close()
+
+	client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0))
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2500 * time.Minute)
+	candles, err := client.GetOHLC(BTC_MXN, Bucket1m, start, end)
+	if err != nil {
+		t.Fatalf("GetOHLC() error = %v", err)
+	}
+
+	if requests != 3 {
+		t.Errorf("got %d requests, want 3", requests)
+	}
+
+	if len(candles) != 2500 {
+		t.Fatalf("got %d candles, want 2500", len(candles))
+	}
+
+	for i, candle := range candles {
+		if want := start.Add(time.Duration(i) * time.Minute); !candle.BucketStart.Equal(want) {
+			t.Fatalf("candles[%d].BucketStart = %s, want %s", i, candle.BucketStart, want)
+		}
+	}
+
+	first := candles[0]
+	if first.Book != string(BTC_MXN) || first.Open.String() != "17040672.00" || first.Close.String() != "17040673.00" ||
+		first.High.String() != "17040674.00" || first.Trades != 3 || first.Volume.String() != "0.5" {
+		t.Errorf("candles[0] = %+v", first)
+	}
+}
+
+func TestGetOHLCInvalidRange(t *testing.T) {
+	var requests int
+	srv := newOHLCServer(t, &requests)
+	defer srv.Close()
+
+	client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := client.GetOHLC(BTC_MXN, TimeBucket(2*time.Minute), start, start.Add(time.Hour)); !errors.Is(err, ErrInvalidTimeBucket) {
+		t.Errorf("GetOHLC() error = %v, want %v", err, ErrInvalidTimeBucket)
+	}
+
+	candles, err := client.GetOHLC(BTC_MXN, Bucket1h, start, start)
+	if err != nil || len(candles) != 0 {
+		t.Errorf("GetOHLC() of an empty range = %v, %v", candles, err)
+	}
+
+	if requests != 0 {
+		t.Errorf("got %d requests, want 0", requests)
+	}
+}
@@ -0,0 +1,127 @@
+package bitso_client
+
+import (
+	"net/http"
+	"time"
+)
+
+// defaultTimeout is the time a single request can take before it is canceled.
+const defaultTimeout = 30 * time.Second
+
+// defaultUserAgent is the User-Agent header sent by the client.
+const defaultUserAgent = "bitso_client-go/0.1.0"
+
+// Option configures the Bitso client created by NewClient.
+type Option func(*bitsoClient)
+
+// WithEnvironment sets the environment the requests are sent to.
+func WithEnvironment(env Environment) Option {
+	return func(c *bitsoClient) {
+		c.env = env
+	}
+}
+
+// WithBaseUrl sets the base URL of the API (ex. an httptest server, a proxy
+// or a recorded fixture). It must include the API version path, if any.
+// It is a shortcut of WithEnvironment(Custom(baseUrl)).
+func WithBaseUrl(baseUrl string) Option {
+	return WithEnvironment(Custom(baseUrl))
+}
+
+// WithProductionMode selects the production environment if productionMode is
+// true, or the sandbox environment otherwise.
+//
+// Deprecated: use WithEnvironment instead.
+func WithProductionMode(productionMode bool) Option {
+	if productionMode {
+		return WithEnvironment(Production)
+	}
+
+	return WithEnvironment(Sandbox)
+}
+
+// WithSignedEnvironments sets the environments the private and trading calls
+// can be sent to; any Custom environment matches Custom. By default, they are
+// allowed in Stage, Sandbox and Custom environments, but not in Production.
+// Example:
+//
+//	client := NewTradingClient(NewEnvCredentials(),
+//		WithEnvironment(Production),
+//		WithSignedEnvironments(Production),
+//	)
+func WithSignedEnvironments(envs ...Environment) Option {
+	return func(c *bitsoClient) {
+		c.signedEnvironments = make([]string, len(envs))
+		for i, env := range envs {
+			c.signedEnvironments[i] = env.name
+		}
+	}
+}
+
+// WithHttpClient sets the HTTP client used to send the requests. A nil
+// client keeps the default one.
+func WithHttpClient(httpClient *http.Client) Option {
+	return func(c *bitsoClient) {
+		if httpClient == nil {
+			httpClient = &http.Client{}
+		}
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransport sets the RoundTripper used to send the requests, keeping the
+// rest of the HTTP client configuration, if any.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *bitsoClient) {
+		var httpClient http.Client
+		if c.httpClient != nil {
+			httpClient = *c.httpClient
+		}
+		httpClient.Transport = transport
+		c.httpClient = &httpClient
+	}
+}
+
+// WithTimeout sets the time a single request can take before it is canceled.
+// A timeout <= 0 disables the per-request timeout, leaving the requests
+// bounded by the context of the caller and the HTTP client only.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *bitsoClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key, value string) Option {
+	return func(c *bitsoClient) {
+		c.headers.Add(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *bitsoClient) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithBookCatalog sets the time the available books are cached for, used to
+// validate book names locally. A ttl <= 0 disables the local validation.
+func WithBookCatalog(ttl time.Duration) Option {
+	return func(c *bitsoClient) {
+		if ttl <= 0 {
+			c.books = nil
+			return
+		}
+		c.books = newBookCatalog(ttl)
+	}
+}
+
+// WithRateLimiter sets the limiter of the requests sent by the client, which
+// can be shared between clients. A nil limiter disables the rate limiting.
+// By default, every client has its own limiter with the Bitso limits.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(c *bitsoClient) {
+		c.limiter = limiter
+	}
+}
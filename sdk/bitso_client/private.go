@@ -0,0 +1,360 @@
+package bitso_client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMissingCredentials represents an error when a private endpoint is
+// called without credentials.
+var ErrMissingCredentials = errors.New("missing API credentials")
+
+// Credentials represents the API key and secret used to sign the requests.
+type Credentials struct {
+	Key    string
+	Secret string
+}
+
+// CredentialsProvider supplies the credentials used to sign the requests of
+// the private endpoints. It is called before every signed request, so
+// implementations can rotate the credentials.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+type staticCredentials struct {
+	credentials Credentials
+}
+
+// NewStaticCredentials creates a provider that always returns the given
+// API key and secret.
+func NewStaticCredentials(key, secret string) CredentialsProvider {
+	return &staticCredentials{credentials: Credentials{Key: key, Secret: secret}}
+}
+
+func (p *staticCredentials) Credentials(context.Context) (Credentials, error) {
+	return p.credentials, nil
+}
+
+type envCredentials struct {
+	keyVar    string
+	secretVar string
+}
+
+// NewEnvCredentials creates a provider that reads the API key and secret from
+// the BITSO_API_KEY and BITSO_API_SECRET environment variables.
+func NewEnvCredentials() CredentialsProvider {
+	return &envCredentials{keyVar: "BITSO_API_KEY", secretVar: "BITSO_API_SECRET"}
+}
+
+func (p *envCredentials) Credentials(context.Context) (Credentials, error) {
+	c := Credentials{Key: os.Getenv(p.keyVar), Secret: os.Getenv(p.secretVar)}
+	if c.Key == "" || c.Secret == "" {
+		return c, fmt.Errorf("%w: %s and %s must be set", ErrMissingCredentials, p.keyVar, p.secretVar)
+	}
+
+	return c, nil
+}
+
+// nonceGenerator returns strictly increasing nonces based on the current
+// time in milliseconds, as required by the API for every API key.
+type nonceGenerator struct {
+	last atomic.Int64
+}
+
+func (g *nonceGenerator) next() int64 {
+	for {
+		last := g.last.Load()
+		nonce := max(time.Now().UnixMilli(), last+1)
+		if g.last.CompareAndSwap(last, nonce) {
+			return nonce
+		}
+	}
+}
+
+// signature returns the hex encoded HMAC-SHA256 of the concatenation of the
+// nonce, the HTTP method, the request path (including the query string) and
+// the JSON payload, using the API secret as the key.
+// ref: https://docs.bitso.com/bitso-api/docs/create-signed-requests
+func signature(secret string, nonce int64, method, requestPath string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d%s%s", nonce, method, requestPath)))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sign adds the `Authorization: Bitso <key>:<nonce>:<signature>` header to the request.
+func (c *bitsoClient) sign(ctx context.Context, req *http.Request, payload []byte) error {
+	if c.credentials == nil {
+		return ErrMissingCredentials
+	}
+
+	creds, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	requestPath := req.URL.EscapedPath()
+	if req.URL.RawQuery != "" {
+		requestPath += "?" + req.URL.RawQuery
+	}
+
+	nonce := c.nonce.next()
+	req.Header.Set("Authorization", fmt.Sprintf("Bitso %s:%d:%s",
+		creds.Key, nonce, signature(creds.Secret, nonce, req.Method, requestPath, payload)))
+
+	return nil
+}
+
+// Balance represents the balance of a currency.
+// Unlike the public payloads, the amounts of the private ones (Balance,
+// CustomerFee, Fees, BalanceUpdate and Order) are kept as the strings sent
+// by the API; parse them with decimal.Parse when doing math with them.
+type Balance struct {
+	Currency          string `json:"currency"`
+	Total             string `json:"total"`
+	Locked            string `json:"locked"`
+	Available         string `json:"available"`
+	PendingDeposit    string `json:"pending_deposit"`
+	PendingWithdrawal string `json:"pending_withdrawal"`
+}
+
+type bitsoBalanceResponse struct {
+	bitsoBaseResponse
+	Payload struct {
+		Balances []Balance `json:"balances"`
+	} `json:"payload"`
+}
+
+// CustomerFee represents the trading fees of the user in a book.
+type CustomerFee struct {
+	Book            string `json:"book"`
+	FeePercent      string `json:"fee_percent"`
+	FeeDecimal      string `json:"fee_decimal"`
+	TakerFeePercent string `json:"taker_fee_percent"`
+	TakerFeeDecimal string `json:"taker_fee_decimal"`
+	MakerFeePercent string `json:"maker_fee_percent"`
+	MakerFeeDecimal string `json:"maker_fee_decimal"`
+}
+
+// Fees represents the trading and withdrawal fees of the user.
+// WithdrawalFees is keyed by currency.
+type Fees struct {
+	Fees           []CustomerFee     `json:"fees"`
+	WithdrawalFees map[string]string `json:"withdrawal_fees"`
+}
+
+type bitsoFeesResponse struct {
+	bitsoBaseResponse
+	Payload Fees `json:"payload"`
+}
+
+// BalanceUpdate represents the change of a currency balance in a ledger entry.
+type BalanceUpdate struct {
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+// LedgerEntry represents an operation of the user ledger.
+// Operation is one of "trade", "fee", "funding" or "withdrawal", and the
+// content of Details depends on it.
+type LedgerEntry struct {
+	Eid            string          `json:"eid"`
+	Operation      string          `json:"operation"`
+	CreatedAt      time.Time       `json:"created_at"`
+	BalanceUpdates []BalanceUpdate `json:"balance_updates"`
+	Details        map[string]any  `json:"details"`
+}
+
+type bitsoLedgerResponse struct {
+	bitsoBaseResponse
+	Payload []LedgerEntry `json:"payload"`
+}
+
+// LedgerParams represents the pagination parameters of the ledger endpoint.
+// Zero values are omitted from the request, so the API defaults apply.
+type LedgerParams struct {
+	Marker string        // Marker returns the entries older (desc) or newer (asc) than the given eid.
+	Sort   SortDirection // Sort is the sorting direction by eid.
+	Limit  int           // Limit is the number of entries to return (max. 100).
+}
+
+// Order represents an order of the user.
+type Order struct {
+	Oid            string    `json:"oid"`
+	Book           string    `json:"book"`
+	OriginalAmount string    `json:"original_amount"`
+	UnfilledAmount string    `json:"unfilled_amount"`
+	OriginalValue  string    `json:"original_value"`
+	Price          string    `json:"price"`
+	Side           string    `json:"side"`
+	Status         string    `json:"status"`
+	Type           string    `json:"type"`
+	TimeInForce    string    `json:"time_in_force,omitempty"`
+	ClientId       string    `json:"origin_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type bitsoOrdersResponse struct {
+	bitsoBaseResponse
+	Payload []Order `json:"payload"`
+}
+
+// PrivateClient represents the Bitso client with access to the private
+// endpoints, whose requests are signed with the user credentials.
+type PrivateClient interface {
+	Client
+	GetBalance() ([]Balance, error)
+	GetBalanceContext(ctx context.Context) ([]Balance, error)
+	GetFees() (Fees, error)
+	GetFeesContext(ctx context.Context) (Fees, error)
+	GetLedger(params LedgerParams) ([]LedgerEntry, error)
+	GetLedgerContext(ctx context.Context, params LedgerParams) ([]LedgerEntry, error)
+	GetOpenOrders(ticker TickerName) ([]Order, error)
+	GetOpenOrdersContext(ctx context.Context, ticker TickerName) ([]Order, error)
+}
+
+// NewPrivateClient creates a new instance of the Bitso client that signs the
+// requests of the private endpoints with the given credentials.
+// See NewClient for the available options.
+// Example:
+//
+//	client := NewPrivateClient(NewEnvCredentials())
+//	balances, err := client.GetBalance()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, b := range balances {
+//		fmt.Printf("%s: %s\n", b.Currency, b.Available)
+//	}
+func NewPrivateClient(credentials CredentialsProvider, opts ...Option) PrivateClient {
+	c := NewClient(opts...).(*bitsoClient)
+	c.credentials = credentials
+
+	return c
+}
+
+// getBalance calls `<bitsoBaseUrl>/balance` to retrieve the balances of the
+// user and returns the result if "success" == true.
+// Otherwise, it returns an error.
+// ref: https://docs.bitso.com/bitso-api/docs/account-balance
+func (c *bitsoClient) getBalance(ctx context.Context) ([]Balance, error) {
+	var resp bitsoBalanceResponse
+	if err := c.do(ctx, http.MethodGet, "/balance", nil, nil, true, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	return resp.Payload.Balances, nil
+}
+
+// getFees calls `<bitsoBaseUrl>/fees` to retrieve the fees of the user and
+// returns the result if "success" == true.
+// Otherwise, it returns an error.
+// ref: https://docs.bitso.com/bitso-api/docs/list-fees
+func (c *bitsoClient) getFees(ctx context.Context) (Fees, error) {
+	var resp bitsoFeesResponse
+	if err := c.do(ctx, http.MethodGet, "/fees", nil, nil, true, &resp); err != nil {
+		return Fees{}, fmt.Errorf("failed to get fees: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+// getLedger calls `<bitsoBaseUrl>/ledger?marker=<marker>&sort=<sort>&limit=<limit>`
+// to retrieve the ledger of the user and returns the result if "success" == true.
+// Otherwise, it returns an error.
+// ref: https://docs.bitso.com/bitso-api/docs/list-ledger
+func (c *bitsoClient) getLedger(ctx context.Context, p LedgerParams) ([]LedgerEntry, error) {
+	params := url.Values{}
+	if p.Marker != "" {
+		params.Set("marker", p.Marker)
+	}
+	if p.Sort != "" {
+		params.Set("sort", string(p.Sort))
+	}
+	if p.Limit > 0 {
+		params.Set("limit", fmt.Sprint(p.Limit))
+	}
+
+	var resp bitsoLedgerResponse
+	if err := c.do(ctx, http.MethodGet, "/ledger", params, nil, true, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get ledger: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+// getOpenOrders calls `<bitsoBaseUrl>/open_orders?book=<name>` to retrieve
+// the open orders of the user and returns the result if "success" == true.
+// Otherwise, it returns an error.
+// ref: https://docs.bitso.com/bitso-api/docs/list-open-orders
+func (c *bitsoClient) getOpenOrders(ctx context.Context, name TickerName) ([]Order, error) {
+	if err := c.checkBook(ctx, name); err != nil {
+		return nil, fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("book", string(name))
+
+	var resp bitsoOrdersResponse
+	if err := c.do(ctx, http.MethodGet, "/open_orders", params, nil, true, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+// GetBalance retrieves the balances of the user.
+func (c *bitsoClient) GetBalance() ([]Balance, error) {
+	return c.GetBalanceContext(context.Background())
+}
+
+// GetBalanceContext retrieves the balances of the user, canceling the
+// request when ctx is done.
+func (c *bitsoClient) GetBalanceContext(ctx context.Context) ([]Balance, error) {
+	return c.getBalance(ctx)
+}
+
+// GetFees retrieves the trading and withdrawal fees of the user.
+func (c *bitsoClient) GetFees() (Fees, error) {
+	return c.GetFeesContext(context.Background())
+}
+
+// GetFeesContext retrieves the trading and withdrawal fees of the user,
+// canceling the request when ctx is done.
+func (c *bitsoClient) GetFeesContext(ctx context.Context) (Fees, error) {
+	return c.getFees(ctx)
+}
+
+// GetLedger retrieves a page of the ledger of the user.
+// Use the eid of the last entry as the Marker of the next page.
+func (c *bitsoClient) GetLedger(params LedgerParams) ([]LedgerEntry, error) {
+	return c.GetLedgerContext(context.Background(), params)
+}
+
+// GetLedgerContext retrieves a page of the ledger of the user, canceling the
+// request when ctx is done.
+func (c *bitsoClient) GetLedgerContext(ctx context.Context, params LedgerParams) ([]LedgerEntry, error) {
+	return c.getLedger(ctx, params)
+}
+
+// GetOpenOrders retrieves the open orders of the user in the given book.
+func (c *bitsoClient) GetOpenOrders(ticker TickerName) ([]Order, error) {
+	return c.GetOpenOrdersContext(context.Background(), ticker)
+}
+
+// GetOpenOrdersContext retrieves the open orders of the user in the given
+// book, canceling the request when ctx is done.
+func (c *bitsoClient) GetOpenOrdersContext(ctx context.Context, ticker TickerName) ([]Order, error) {
+	return c.getOpenOrders(ctx, ticker)
+}
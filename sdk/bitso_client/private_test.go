@@ -0,0 +1,196 @@
+package bitso_client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testApiKey    = "test-key"
+	testApiSecret = "test-secret"
+)
+
+// newSignedServer verifies the signature of every request against the test
+// credentials, replying 401 when it doesn't match or the nonce doesn't
+// increase. Otherwise, it replies with the response of the request path.
+func newSignedServer(responses map[string]string) *httptest.Server {
+	var mutex sync.Mutex
+	var lastNonce int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+		if !verifySignature(w, r, body, &lastNonce) {
+			return
+		}
+
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+}
+
+// verifySignature checks the Authorization header of a request signed with
+// the test credentials, replying 401 when it doesn't match or the nonce
+// doesn't increase.
+func verifySignature(w http.ResponseWriter, r *http.Request, body []byte, lastNonce *int64) bool {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bitso ")
+	parts := strings.Split(auth, ":")
+	if !ok || len(parts) != 3 || parts[0] != testApiKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"success": false, "error": {"code": "0201", "message": "Invalid credentials"}}`))
+		return false
+	}
+
+	nonce, _ := strconv.ParseInt(parts[1], 10, 64)
+	want := signature(testApiSecret, nonce, r.Method, r.URL.RequestURI(), body)
+	if parts[2] != want || nonce <= *lastNonce {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"success": false, "error": {"code": "0201", "message": "Invalid Nonce or Invalid Signature"}}`))
+		return false
+	}
+	*lastNonce = nonce
+
+	return true
+}
+
+func TestSignature(t *testing.T) {
+	// ref: https://docs.bitso.com/bitso-api/docs/create-signed-requests
+	got := signature("secret", 1, http.MethodGet, "/api/v3/balance/", nil)
+	if len(got) != 64 {
+		t.Errorf("signature() = %s, want an hex encoded sha256", got)
+	}
+
+	if got == signature("secret", 2, http.MethodGet, "/api/v3/balance/", nil) {
+		t.Errorf("signature() does not depend on the nonce")
+	}
+
+	if got == signature("secret", 1, http.MethodGet, "/api/v3/balance/", []byte("{}")) {
+		t.Errorf("signature() does not depend on the payload")
+	}
+}
+
+func TestPrivateClient(t *testing.T) {
+	srv := newSignedServer(map[string]string{
+		"/balance": `{"success": true, "payload": {"balances": [
+			{"currency": "mxn", "total": "100.25", "locked": "25.00", "available": "75.25",
+			 "pending_deposit": "0", "pending_withdrawal": "0"}]}}`,
+		"/fees": `{"success": true, "payload": {
+			"fees": [{"book": "btc_mxn", "fee_percent": "0.6500", "fee_decimal": "0.0065",
+			          "taker_fee_percent": "0.6500", "taker_fee_decimal": "0.0065",
+			          "maker_fee_percent": "0.5000", "maker_fee_decimal": "0.0050"}],
+			"withdrawal_fees": {"btc": "0.001"}}}`,
+		"/ledger": `{"success": true, "payload": [
+			{"eid": "c4ca4238a0b923820dcc509a6f75849b", "operation": "trade",
+			 "created_at": "2016-04-08T17:52:31.000+00:00",
+			 "balance_updates": [{"currency": "btc", "amount": "-0.25232073"}],
+			 "details": {"tid": 51756, "oid": "wri0yg8miihs80ngk"}}]}`,
+		"/open_orders": `{"success": true, "payload": [
+			{"oid": "543cr2v32a1h684430tvcqx1b0vkr93wd694957cg8umhyrlzkgbaedmf976ia3v",
+			 "book": "btc_mxn", "original_amount": "0.01000000", "unfilled_amount": "0.00500000",
+			 "original_value": "56.0", "price": "5600.00", "side": "buy", "status": "partially filled",
+			 "type": "limit", "created_at": "2016-04-08T17:52:31.000+00:00",
+			 "updated_at": "2016-04-08T17:52:51.000+00:00"}]}`,
+	})
+	defer srv.Close()
+
+	client := NewPrivateClient(NewStaticCredentials(testApiKey, testApiSecret),
+		WithBaseUrl(srv.URL), WithBookCatalog(0))
+
+	balances, err := client.GetBalance()
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	if len(balances) != 1 || balances[0].Currency != "mxn" || balances[0].Available != "75.25" {
+		t.Errorf("GetBalance() = %+v", balances)
+	}
+
+	fees, err := client.GetFees()
+	if err != nil {
+		t.Fatalf("GetFees() error = %v", err)
+	}
+	if len(fees.Fees) != 1 || fees.Fees[0].MakerFeeDecimal != "0.0050" || fees.WithdrawalFees["btc"] != "0.001" {
+		t.Errorf("GetFees() = %+v", fees)
+	}
+
+	ledger, err := client.GetLedger(LedgerParams{Marker: "c4ca", Sort: SortDesc, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetLedger() error = %v", err)
+	}
+	if len(ledger) != 1 || ledger[0].Operation != "trade" || ledger[0].BalanceUpdates[0].Amount != "-0.25232073" {
+		t.Errorf("GetLedger() = %+v", ledger)
+	}
+
+	orders, err := client.GetOpenOrders(BTC_MXN)
+	if err != nil {
+		t.Fatalf("GetOpenOrders() error = %v", err)
+	}
+	if len(orders) != 1 || orders[0].UnfilledAmount != "0.00500000" || orders[0].Side != "buy" {
+		t.Errorf("GetOpenOrders() = %+v", orders)
+	}
+}
+
+func TestPrivateClientCredentials(t *testing.T) {
+	srv := newSignedServer(map[string]string{"/balance": `{"success": true, "payload": {"balances": []}}`})
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		credentials CredentialsProvider
+		env         map[string]string
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name:        "missing credentials provider",
+			credentials: nil,
+			wantErr:     true,
+			wantErrIs:   ErrMissingCredentials,
+		},
+		{
+			name:        "missing environment variables",
+			credentials: NewEnvCredentials(),
+			env:         map[string]string{"BITSO_API_KEY": "", "BITSO_API_SECRET": ""},
+			wantErr:     true,
+			wantErrIs:   ErrMissingCredentials,
+		},
+		{
+			name:        "environment variables",
+			credentials: NewEnvCredentials(),
+			env:         map[string]string{"BITSO_API_KEY": testApiKey, "BITSO_API_SECRET": testApiSecret},
+		},
+		{
+			name:        "wrong secret",
+			credentials: NewStaticCredentials(testApiKey, "wrong-secret"),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			client := NewPrivateClient(tt.credentials, WithBaseUrl(srv.URL), WithBookCatalog(0))
+			_, err := client.GetBalance()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetBalance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("GetBalance() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
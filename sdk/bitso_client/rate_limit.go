@@ -0,0 +1,244 @@
+package bitso_client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EndpointClass represents a group of endpoints sharing the same request limit.
+type EndpointClass string
+
+const (
+	PublicEndpoints  EndpointClass = "public"  // PublicEndpoints for the unsigned requests, limited per IP.
+	PrivateEndpoints EndpointClass = "private" // PrivateEndpoints for the signed requests, limited per user.
+)
+
+// defaultRateLimits holds the request limits enforced by Bitso.
+// ref: https://docs.bitso.com/bitso-api/docs/rate-limits
+var defaultRateLimits = map[EndpointClass]RateLimit{
+	PublicEndpoints:  {Requests: 60, Interval: time.Minute},
+	PrivateEndpoints: {Requests: 300, Interval: time.Minute},
+}
+
+// defaultRetryAfter is the time the requests are paused after a 429 response
+// without a valid Retry-After header. Bitso blocks the offending IP for a minute.
+const defaultRetryAfter = time.Minute
+
+// RateLimit represents the number of requests allowed per interval.
+// Requests can be sent in bursts of up to Requests requests.
+// A RateLimit with Requests <= 0 or Interval <= 0 disables the limit.
+type RateLimit struct {
+	Requests int
+	Interval time.Duration
+}
+
+// RateLimitState represents the current state of the limiter of an endpoint class.
+type RateLimitState struct {
+	Class       EndpointClass `json:"class"`
+	Limit       int           `json:"limit"`
+	Interval    time.Duration `json:"interval"`
+	Available   float64       `json:"available"`    // Available is the number of requests that can be sent right away.
+	PausedUntil time.Time     `json:"paused_until"` // PausedUntil is set while a Retry-After is being honored.
+}
+
+// tokenBucket limits the requests of an endpoint class. Tokens are refilled
+// continuously at Requests/Interval and every request takes one.
+type tokenBucket struct {
+	limit       RateLimit
+	tokens      float64
+	updatedAt   time.Time
+	pausedUntil time.Time
+}
+
+// refill adds the tokens earned since the last update.
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updatedAt); elapsed > 0 {
+		b.tokens += float64(b.limit.Requests) * float64(elapsed) / float64(b.limit.Interval)
+		b.tokens = min(b.tokens, float64(b.limit.Requests))
+		b.updatedAt = now
+	}
+}
+
+// reserve takes a token if one is available, or returns the time to wait
+// for the next one.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if now.Before(b.pausedUntil) {
+		return b.pausedUntil.Sub(now)
+	}
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) * float64(b.limit.Interval) / float64(b.limit.Requests))
+}
+
+// RateLimiter represents a client-side token bucket limiter of the requests
+// sent to the API, configured per endpoint class.
+// A RateLimiter can be shared between clients (ex. a public and a private
+// client) so their requests count against the same limits.
+// All the methods are safe for concurrent use.
+type RateLimiter struct {
+	mutex   sync.Mutex
+	buckets map[EndpointClass]*tokenBucket
+	now     func() time.Time
+}
+
+// NewRateLimiter creates a limiter with the Bitso limits, overridden by the
+// given ones. Classes with a disabled RateLimit are not limited.
+// Example:
+//
+//	limiter := NewRateLimiter(map[EndpointClass]RateLimit{
+//		PublicEndpoints: {Requests: 30, Interval: time.Minute},
+//	})
+//	client := NewClient(WithRateLimiter(limiter))
+func NewRateLimiter(limits map[EndpointClass]RateLimit) *RateLimiter {
+	l := &RateLimiter{
+		buckets: make(map[EndpointClass]*tokenBucket),
+		now:     time.Now,
+	}
+
+	for class, limit := range defaultRateLimits {
+		l.SetLimit(class, limit)
+	}
+	for class, limit := range limits {
+		l.SetLimit(class, limit)
+	}
+
+	return l
+}
+
+// SetLimit sets the limit of the given endpoint class, starting with a full bucket.
+func (l *RateLimiter) SetLimit(class EndpointClass, limit RateLimit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if limit.Requests <= 0 || limit.Interval <= 0 {
+		delete(l.buckets, class)
+		return
+	}
+
+	l.buckets[class] = &tokenBucket{
+		limit:     limit,
+		tokens:    float64(limit.Requests),
+		updatedAt: l.now(),
+	}
+}
+
+// Wait blocks until a request of the given endpoint class can be sent, or
+// returns ctx.Err() if ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context, class EndpointClass) error {
+	for {
+		l.mutex.Lock()
+		var wait time.Duration
+		if b, ok := l.buckets[class]; ok {
+			wait = b.reserve(l.now())
+		}
+		l.mutex.Unlock()
+
+		if wait <= 0 {
+			return ctx.Err()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Pause holds the requests of the given endpoint class for d (ex. after a
+// 429 response). Requests already waiting are held too.
+func (l *RateLimiter) Pause(class EndpointClass, d time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b, ok := l.buckets[class]
+	if !ok {
+		return
+	}
+
+	now := l.now()
+	if until := now.Add(d); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+	// The server counted more requests than we did: start over once resumed.
+	b.tokens = 0
+	b.updatedAt = b.pausedUntil
+}
+
+// State returns the current state of the limiter of every endpoint class.
+func (l *RateLimiter) State() []RateLimitState {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	states := make([]RateLimitState, 0, len(l.buckets))
+	for _, class := range []EndpointClass{PublicEndpoints, PrivateEndpoints} {
+		if b, ok := l.buckets[class]; ok {
+			states = append(states, b.state(class, now))
+		}
+	}
+	for class, b := range l.buckets {
+		if class != PublicEndpoints && class != PrivateEndpoints {
+			states = append(states, b.state(class, now))
+		}
+	}
+
+	return states
+}
+
+// state returns the state of the bucket at the given time.
+func (b *tokenBucket) state(class EndpointClass, now time.Time) RateLimitState {
+	s := RateLimitState{
+		Class:    class,
+		Limit:    b.limit.Requests,
+		Interval: b.limit.Interval,
+	}
+
+	if now.Before(b.pausedUntil) {
+		s.PausedUntil = b.pausedUntil
+		return s
+	}
+
+	b.refill(now)
+	s.Available = b.tokens
+
+	return s
+}
+
+// parseRetryAfter returns the time to wait given by a Retry-After header,
+// either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+// RateLimit returns the state of the rate limits of the client, by endpoint
+// class, or nil if its requests are not rate limited.
+func (c *bitsoClient) RateLimit() []RateLimitState {
+	if c.limiter == nil {
+		return nil
+	}
+
+	return c.limiter.State()
+}
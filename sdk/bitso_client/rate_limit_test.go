@@ -0,0 +1,141 @@
+package bitso_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(map[EndpointClass]RateLimit{
+		PublicEndpoints:  {Requests: 2, Interval: time.Second},
+		PrivateEndpoints: {},
+	})
+	limiter.now = func() time.Time { return now }
+	limiter.SetLimit(PublicEndpoints, RateLimit{Requests: 2, Interval: time.Second})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(ctx, PublicEndpoints); err != nil {
+			t.Fatalf("Wait() #%d error = %v", i, err)
+		}
+	}
+
+	// The bucket is empty: the next request must wait for half a second.
+	expired, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(expired, PublicEndpoints); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() on an empty bucket error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if err := limiter.Wait(ctx, PublicEndpoints); err != nil {
+		t.Errorf("Wait() after refill error = %v", err)
+	}
+
+	// Disabled classes are not limited.
+	for i := 0; i < 10; i++ {
+		if err := limiter.Wait(ctx, PrivateEndpoints); err != nil {
+			t.Fatalf("Wait() on a disabled class error = %v", err)
+		}
+	}
+
+	limiter.Pause(PublicEndpoints, time.Minute)
+	states := limiter.State()
+	if len(states) != 1 {
+		t.Fatalf("State() = %+v, want only the public class", states)
+	}
+	if got := states[0]; got.Class != PublicEndpoints || got.Limit != 2 || !got.PausedUntil.Equal(now.Add(time.Minute)) {
+		t.Errorf("State() = %+v", got)
+	}
+
+	now = now.Add(time.Minute + time.Second)
+	if got := limiter.State()[0]; !got.PausedUntil.IsZero() || got.Available != 2 {
+		t.Errorf("State() after the pause = %+v", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{value: "", wantOk: false},
+		{value: "30", want: 30 * time.Second, wantOk: true},
+		{value: now.Add(2 * time.Minute).Format(http.TimeFormat), want: 2 * time.Minute, wantOk: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOk: true},
+		{value: "soon", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	limiter := NewRateLimiter(nil)
+	client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0), WithRateLimiter(limiter))
+
+	_, err := client.GetTicker(BTC_MXN)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 2*time.Minute {
+		t.Fatalf("GetTicker() error = %v, want an *APIError with RetryAfter = 2m", err)
+	}
+
+	states := limiter.State()
+	if len(states) == 0 || states[0].Class != PublicEndpoints || states[0].PausedUntil.IsZero() {
+		t.Fatalf("State() = %+v, want the public class paused", states)
+	}
+
+	// The next request is held by the limiter instead of hitting the server.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = client.GetTickerContext(ctx, BTC_MXN); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetTickerContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success": true, "payload": {"book": "btc_mxn"}}`))
+	}))
+	defer srv.Close()
+
+	// The default limiter of the client is reachable too.
+	client := NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0))
+	before := client.RateLimit()
+	if len(before) == 0 || before[0].Class != PublicEndpoints {
+		t.Fatalf("RateLimit() = %+v, want the public class", before)
+	}
+
+	if _, err := client.GetTicker(BTC_MXN); err != nil {
+		t.Fatalf("GetTicker() error = %v", err)
+	}
+	if after := client.RateLimit(); after[0].Available >= before[0].Available {
+		t.Errorf("RateLimit() after a request = %+v, want a token taken from %+v", after[0], before[0])
+	}
+
+	if states := NewClient(WithRateLimiter(nil)).RateLimit(); states != nil {
+		t.Errorf("RateLimit() without a limiter = %+v, want nil", states)
+	}
+}
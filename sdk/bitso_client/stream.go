@@ -0,0 +1,346 @@
+package bitso_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+const bitsoWebSocketUrl = "wss://ws.bitso.com"
+
+const (
+	defaultStreamMinBackoff  = time.Second
+	defaultStreamMaxBackoff  = time.Minute
+	defaultStreamReadTimeout = time.Minute
+
+	// streamBackoffFloor is the lowest backoff allowed, so a connection that
+	// keeps failing doesn't make the stream reconnect in a tight loop.
+	streamBackoffFloor = 10 * time.Millisecond
+)
+
+// StreamChannel represents the name of a WebSocket channel.
+type StreamChannel string
+
+const (
+	TradesChannel     StreamChannel = "trades"      // TradesChannel for the executed trades.
+	DiffOrdersChannel StreamChannel = "diff-orders" // DiffOrdersChannel for the changes in the order book.
+	OrdersChannel     StreamChannel = "orders"      // OrdersChannel for the top of the order book.
+)
+
+// StreamTrade represents a trade received through the trades channel.
+// MakerSide is 0 when the maker order is a buy and 1 when it is a sell.
+// CreatedAt is a unix timestamp in milliseconds.
+type StreamTrade struct {
+	Tid       int64           `json:"i"`
+	Amount    decimal.Decimal `json:"a"`
+	Rate      decimal.Decimal `json:"r"`
+	Value     decimal.Decimal `json:"v"`
+	MakerSide int             `json:"t"`
+	MakerOid  string          `json:"mo"`
+	TakerOid  string          `json:"to"`
+	CreatedAt int64           `json:"x"`
+}
+
+// StreamOrder represents an order received through the diff-orders and
+// orders channels.
+// Side is 0 for buy orders and 1 for sell orders, Timestamp is a unix
+// timestamp in milliseconds and Status is one of "open", "cancelled" or
+// "completed". Amount and Value are zero when the order is removed.
+type StreamOrder struct {
+	Oid       string          `json:"o"`
+	Rate      decimal.Decimal `json:"r"`
+	Amount    decimal.Decimal `json:"a"`
+	Value     decimal.Decimal `json:"v"`
+	Side      int             `json:"t"`
+	Timestamp int64           `json:"d"`
+	Status    string          `json:"s"`
+}
+
+// TradesMessage represents a message of the trades channel.
+type TradesMessage struct {
+	Book    string        `json:"book"`
+	Payload []StreamTrade `json:"payload"`
+}
+
+// DiffOrdersMessage represents a message of the diff-orders channel.
+// Messages of a book must be applied in Sequence order.
+type DiffOrdersMessage struct {
+	Book     string        `json:"book"`
+	Sequence int64         `json:"sequence"`
+	Payload  []StreamOrder `json:"payload"`
+}
+
+// OrdersMessage represents a message of the orders channel.
+type OrdersMessage struct {
+	Book    string `json:"book"`
+	Payload struct {
+		Bids []StreamOrder `json:"bids"`
+		Asks []StreamOrder `json:"asks"`
+	} `json:"payload"`
+}
+
+// StreamHandler holds the callbacks invoked by the Stream.
+// Callbacks are called sequentially from the goroutine running the stream,
+// so they should return quickly. Nil callbacks are ignored.
+type StreamHandler struct {
+	OnTrades     func(TradesMessage)
+	OnDiffOrders func(DiffOrdersMessage)
+	OnOrders     func(OrdersMessage)
+	// OnConnect is called every time the subscriptions are (re)established,
+	// before any message of the new connection is delivered.
+	OnConnect func()
+	// OnError is called with the messages that can't be decoded and with the
+	// error that closed a connection, before reconnecting.
+	OnError func(error)
+}
+
+type streamSubscription struct {
+	book    TickerName
+	channel StreamChannel
+}
+
+// Stream represents a WebSocket client of the Bitso streaming API.
+// It subscribes to the requested channels and reconnects with an
+// exponential backoff when the connection is lost.
+type Stream struct {
+	url         string
+	dialer      *websocket.Dialer
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	readTimeout time.Duration
+	handler     StreamHandler
+
+	mutex         sync.Mutex
+	subscriptions []streamSubscription
+}
+
+// StreamOption configures the Stream created by NewStream.
+type StreamOption func(*Stream)
+
+// WithStreamUrl sets the WebSocket URL (ex. a local stand-in server).
+func WithStreamUrl(url string) StreamOption {
+	return func(s *Stream) {
+		s.url = url
+	}
+}
+
+// WithStreamDialer sets the dialer used to open the connections.
+func WithStreamDialer(dialer *websocket.Dialer) StreamOption {
+	return func(s *Stream) {
+		s.dialer = dialer
+	}
+}
+
+// WithStreamBackoff sets the minimum and maximum time to wait before
+// reconnecting. The wait is doubled after every failed attempt.
+// A minimum below 10ms is raised to it, and a maximum below the minimum is
+// raised to the minimum.
+func WithStreamBackoff(minBackoff, maxBackoff time.Duration) StreamOption {
+	return func(s *Stream) {
+		s.minBackoff = max(minBackoff, streamBackoffFloor)
+		s.maxBackoff = max(maxBackoff, s.minBackoff)
+	}
+}
+
+// WithStreamReadTimeout sets the time to wait for a message (including the
+// keep-alive messages sent by the server) before the connection is
+// considered lost.
+func WithStreamReadTimeout(timeout time.Duration) StreamOption {
+	return func(s *Stream) {
+		s.readTimeout = timeout
+	}
+}
+
+// NewStream creates a new instance of the Bitso WebSocket client.
+// Example:
+//
+//	stream := NewStream(StreamHandler{
+//		OnTrades: func(msg TradesMessage) {
+//			for _, trade := range msg.Payload {
+//				fmt.Printf("%s: %s @ %s\n", msg.Book, trade.Amount, trade.Rate)
+//			}
+//		},
+//	})
+//	stream.Subscribe(BTC_MXN, TradesChannel)
+//	if err := stream.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+//		log.Fatal(err)
+//	}
+func NewStream(handler StreamHandler, opts ...StreamOption) *Stream {
+	s := &Stream{
+		url:         bitsoWebSocketUrl,
+		dialer:      websocket.DefaultDialer,
+		minBackoff:  defaultStreamMinBackoff,
+		maxBackoff:  defaultStreamMaxBackoff,
+		readTimeout: defaultStreamReadTimeout,
+		handler:     handler,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Subscribe adds a subscription to the given channel of the given book.
+// It takes effect on the next (re)connection.
+func (s *Stream) Subscribe(book TickerName, channel StreamChannel) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.subscriptions = append(s.subscriptions, streamSubscription{book: book, channel: channel})
+}
+
+// Run connects to the server and delivers the messages to the handler until
+// ctx is done, reconnecting with backoff whenever the connection is lost.
+// It always returns a non-nil error: ctx.Err() once ctx is done.
+func (s *Stream) Run(ctx context.Context) error {
+	backoff := s.minBackoff
+	for {
+		connected, err := s.runConnection(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err != nil && s.handler.OnError != nil {
+			s.handler.OnError(err)
+		}
+
+		if connected {
+			backoff = s.minBackoff
+		}
+
+		// Add up to 20% of jitter, so clients don't reconnect in lockstep.
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)/5+1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// streamEnvelope represents the fields shared by every message.
+type streamEnvelope struct {
+	Action   string `json:"action"`
+	Response string `json:"response"`
+	Type     string `json:"type"`
+	Book     string `json:"book"`
+}
+
+// runConnection opens a connection, subscribes to the channels and reads
+// messages until the connection fails or ctx is done. It reports whether
+// the subscriptions were established.
+func (s *Stream) runConnection(ctx context.Context) (connected bool, err error) {
+	conn, _, err := s.dialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	defer conn.Close()
+
+	// Unblock the read loop when ctx is done.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	s.mutex.Lock()
+	subscriptions := append([]streamSubscription(nil), s.subscriptions...)
+	s.mutex.Unlock()
+
+	for _, sub := range subscriptions {
+		err = conn.WriteJSON(map[string]string{
+			"action": "subscribe",
+			"book":   string(sub.book),
+			"type":   string(sub.channel),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to subscribe to %s(%s): %w", sub.channel, sub.book, err)
+		}
+	}
+
+	if s.handler.OnConnect != nil {
+		s.handler.OnConnect()
+	}
+
+	for {
+		if err = conn.SetReadDeadline(time.Now().Add(s.readTimeout)); err != nil {
+			return true, err
+		}
+
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return true, fmt.Errorf("failed to read message: %w", err)
+		}
+
+		if err = s.dispatch(data); err != nil {
+			// Keep backing off if the server keeps rejecting the subscriptions.
+			if errors.Is(err, errSubscriptionRejected) {
+				return false, err
+			}
+
+			if s.handler.OnError != nil {
+				s.handler.OnError(err)
+			}
+		}
+	}
+}
+
+// errSubscriptionRejected represents an error when the server doesn't
+// acknowledge a subscription.
+var errSubscriptionRejected = errors.New("subscription rejected")
+
+// dispatch decodes a message and delivers it to the matching callback.
+func (s *Stream) dispatch(data []byte) error {
+	var env streamEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	if env.Action == "subscribe" {
+		if env.Response != "ok" {
+			return fmt.Errorf("%w: %s(%s): %s", errSubscriptionRejected, env.Type, env.Book, env.Response)
+		}
+		return nil
+	}
+
+	var err error
+	switch StreamChannel(env.Type) {
+	case TradesChannel:
+		var msg TradesMessage
+		if err = json.Unmarshal(data, &msg); err == nil && s.handler.OnTrades != nil {
+			s.handler.OnTrades(msg)
+		}
+	case DiffOrdersChannel:
+		var msg DiffOrdersMessage
+		if err = json.Unmarshal(data, &msg); err == nil && s.handler.OnDiffOrders != nil {
+			s.handler.OnDiffOrders(msg)
+		}
+	case OrdersChannel:
+		var msg OrdersMessage
+		if err = json.Unmarshal(data, &msg); err == nil && s.handler.OnOrders != nil {
+			s.handler.OnOrders(msg)
+		}
+	default:
+		// Keep-alive ("ka") and unknown messages are ignored.
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to decode %s message: %w", env.Type, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package bitso_client
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// loadStreamFrames reads the captured frames, one JSON message per line.
+func loadStreamFrames(t *testing.T) [][]byte {
+	f, err := os.Open("testdata/stream_frames.jsonl")
+	if err != nil {
+		t.Fatalf("failed to open frames: %v", err)
+	}
+	defer f.Close()
+
+	var frames [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		frames = append(frames, append([]byte(nil), scanner.Bytes()...))
+	}
+
+	return frames
+}
+
+// newStreamServer replays the given frames to every connection after reading
+// the expected number of subscriptions, then closes the connection.
+func newStreamServer(t *testing.T, frames [][]byte, subscriptions int, subscribed chan<- string) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		for i := 0; i < subscriptions; i++ {
+			var msg map[string]string
+			if err = conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg["action"] != "subscribe" {
+				t.Errorf("action = %s, want subscribe", msg["action"])
+			}
+			subscribed <- msg["book"] + ":" + msg["type"]
+		}
+
+		for _, frame := range frames {
+			if err = conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestStream(t *testing.T) {
+	frames := loadStreamFrames(t)
+	subscribed := make(chan string, 100)
+	srv := newStreamServer(t, frames, 3, subscribed)
+	defer srv.Close()
+
+	var mutex sync.Mutex
+	var trades []TradesMessage
+	var diffs []DiffOrdersMessage
+	var orders []OrdersMessage
+	connects := 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := NewStream(StreamHandler{
+		OnTrades: func(msg TradesMessage) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			trades = append(trades, msg)
+		},
+		OnDiffOrders: func(msg DiffOrdersMessage) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			diffs = append(diffs, msg)
+		},
+		OnOrders: func(msg OrdersMessage) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			orders = append(orders, msg)
+		},
+		OnConnect: func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			connects++
+			// Stop after the frames were replayed over a reconnection.
+			if connects == 3 {
+				cancel()
+			}
+		},
+	},
+		WithStreamUrl("ws"+strings.TrimPrefix(srv.URL, "http")),
+		WithStreamBackoff(10*time.Millisecond, 50*time.Millisecond),
+	)
+	stream.Subscribe(BTC_MXN, TradesChannel)
+	stream.Subscribe(BTC_MXN, DiffOrdersChannel)
+	stream.Subscribe(BTC_MXN, OrdersChannel)
+
+	done := make(chan error)
+	go func() {
+		done <- stream.Run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Run() did not return after the context was canceled")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if len(trades) < 2 || len(diffs) < 4 || len(orders) < 2 {
+		t.Fatalf("got %d trades, %d diff-orders and %d orders messages over two connections",
+			len(trades), len(diffs), len(orders))
+	}
+
+	trade := trades[0].Payload[0]
+	if trades[0].Book != "btc_mxn" || trade.Tid != 90129839 || trade.Rate.String() != "629000.00" || trade.MakerSide != 1 {
+		t.Errorf("trades message = %+v", trades[0])
+	}
+
+	if diffs[0].Sequence != 2734 || diffs[1].Sequence != 2735 {
+		t.Errorf("diff-orders sequences = %d, %d, want 2734, 2735", diffs[0].Sequence, diffs[1].Sequence)
+	}
+
+	if diffs[1].Payload[0].Status != "cancelled" || !diffs[1].Payload[0].Amount.IsZero() {
+		t.Errorf("diff-orders message = %+v", diffs[1])
+	}
+
+	if len(orders[0].Payload.Bids) != 1 || orders[0].Payload.Asks[0].Rate.String() != "629100.00" {
+		t.Errorf("orders message = %+v", orders[0])
+	}
+
+	if got := <-subscribed; got != "btc_mxn:trades" {
+		t.Errorf("first subscription = %s, want btc_mxn:trades", got)
+	}
+}
+
+func TestStreamReconnectsAfterDialFailure(t *testing.T) {
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := NewStream(StreamHandler{
+		OnError: func(err error) {
+			errs <- err
+		},
+	},
+		WithStreamUrl("ws://127.0.0.1:1"),
+		WithStreamBackoff(time.Millisecond, 5*time.Millisecond),
+	)
+
+	go func() {
+		_ = stream.Run(ctx)
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-errs:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d connection errors, want 3", i)
+		}
+	}
+}
+
+func TestStreamBackoffFloor(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max time.Duration
+		wantMin  time.Duration
+		wantMax  time.Duration
+	}{
+		{name: "zero", min: 0, max: 0, wantMin: streamBackoffFloor, wantMax: streamBackoffFloor},
+		{name: "negative", min: -time.Second, max: time.Second, wantMin: streamBackoffFloor, wantMax: time.Second},
+		{name: "max below min", min: time.Second, max: time.Millisecond, wantMin: time.Second, wantMax: time.Second},
+		{name: "valid", min: time.Second, max: time.Minute, wantMin: time.Second, wantMax: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewStream(StreamHandler{}, WithStreamBackoff(tt.min, tt.max))
+			if stream.minBackoff != tt.wantMin || stream.maxBackoff != tt.wantMax {
+				t.Errorf("backoff = %v, %v, want %v, %v", stream.minBackoff, stream.maxBackoff, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package bitso_client
+
+import (
+	"context"
+	"time"
+)
+
+// TradeIterator walks backwards through the trade history of a book, from
+// the newest trade down to the given cutoff time, fetching pages on demand.
+// Example:
+//
+//	it := NewTradeIterator(client, BTC_MXN, time.Now().Add(-time.Hour))
+//	for it.Next() {
+//		trade := it.Trade()
+//		fmt.Printf("%d: %s @ %s\n", trade.Tid, trade.Amount, trade.Price)
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+type TradeIterator struct {
+	ctx      context.Context
+	client   Client
+	book     TickerName
+	until    time.Time
+	pageSize int
+
+	page    []Trade
+	marker  int64
+	current Trade
+	last    bool
+	done    bool
+	err     error
+}
+
+// NewTradeIterator creates an iterator over the trades of the given book that
+// were created at or after the until time, newest first.
+func NewTradeIterator(client Client, book TickerName, until time.Time) *TradeIterator {
+	return NewTradeIteratorContext(context.Background(), client, book, until)
+}
+
+// NewTradeIteratorContext creates an iterator like NewTradeIterator, whose
+// page requests are canceled when ctx is done.
+func NewTradeIteratorContext(ctx context.Context, client Client, book TickerName, until time.Time) *TradeIterator {
+	return &TradeIterator{
+		ctx:      ctx,
+		client:   client,
+		book:     book,
+		until:    until,
+		pageSize: MaxTradesLimit,
+	}
+}
+
+// Next advances the iterator to the next trade. It returns false when the
+// cutoff time or the end of the history is reached, or if a request fails.
+func (it *TradeIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.last || !it.fetch() {
+			it.done = true
+			return false
+		}
+	}
+
+	trade := it.page[0]
+	it.page = it.page[1:]
+	if trade.CreatedAt.Before(it.until) {
+		it.done = true
+		return false
+	}
+
+	it.current = trade
+	return true
+}
+
+// Trade returns the current trade.
+func (it *TradeIterator) Trade() Trade {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TradeIterator) Err() error {
+	return it.err
+}
+
+// fetch retrieves the next page of trades, returning false if there are no
+// more trades or the request failed.
+func (it *TradeIterator) fetch() bool {
+	trades, err := it.client.GetTradesPageContext(it.ctx, it.book, TradesParams{
+		Marker: it.marker,
+		Sort:   SortDesc,
+		Limit:  it.pageSize,
+	})
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	if len(trades) == 0 {
+		return false
+	}
+
+	it.page = trades
+	it.marker = trades[len(trades)-1].Tid
+	it.last = len(trades) < it.pageSize
+	return true
+}
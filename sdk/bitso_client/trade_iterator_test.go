@@ -0,0 +1,133 @@
+package bitso_client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTradesServer serves a history of `count` trades, one per minute since
+// start, paginated the same way the Bitso API does (desc sort).
+func newTradesServer(t *testing.T, start time.Time, count int, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		q := r.URL.Query()
+		if q.Get("sort") != string(SortDesc) {
+			t.Errorf("sort = %s, want %s", q.Get("sort"), SortDesc)
+		}
+
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		marker := int64(count + 1)
+		if m := q.Get("marker"); m != "" {
+			marker, _ = strconv.ParseInt(m, 10, 64)
+		}
+
+		payload := []Trade{}
+		for tid := marker - 1; tid > 0 && len(payload) < limit; tid-- {
+			payload = append(payload, Trade{
+				Book:      string(BTC_MXN),
+				CreatedAt: start.Add(time.Duration(tid) * time.Minute),
+				Tid:       tid,
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(bitsoTradesResponse{
+			bitsoBaseResponse: bitsoBaseResponse{Success: true},
+			Payload:           payload,
+		})
+	}))
+}
+
+func TestTradeIterator(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		count        int
+		until        time.Time
+		wantTrades   int
+		wantRequests int
+	}{
+		{
+			name:         "stops at cutoff time",
+			count:        250,
+			until:        start.Add(101 * time.Minute),
+			wantTrades:   150,
+			wantRequests: 2,
+		},
+		{
+			name:         "stops at the end of the history",
+			count:        250,
+			until:        time.Time{},
+			wantTrades:   250,
+			wantRequests: 3,
+		},
+		{
+			name:         "history size is a multiple of the page size",
+			count:        200,
+			until:        time.Time{},
+			wantTrades:   200,
+			wantRequests: 3,
+		},
+		{
+			name:         "empty history",
+			count:        0,
+			until:        time.Time{},
+			wantTrades:   0,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := newTradesServer(t, start, tt.count, &requests)
+			defer srv.Close()
+
+			it := NewTradeIterator(NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0)), BTC_MXN, tt.until)
+			got := 0
+			lastTid := int64(tt.count + 1)
+			for it.Next() {
+				if it.Trade().Tid >= lastTid {
+					t.Errorf("trades are not in descending order: %d after %d", it.Trade().Tid, lastTid)
+				}
+				lastTid = it.Trade().Tid
+				got++
+			}
+
+			if err := it.Err(); err != nil {
+				t.Errorf("TradeIterator.Err() = %v", err)
+			}
+
+			if got != tt.wantTrades {
+				t.Errorf("TradeIterator got %d trades, want %d", got, tt.wantTrades)
+			}
+
+			if requests != tt.wantRequests {
+				t.Errorf("TradeIterator made %d requests, want %d", requests, tt.wantRequests)
+			}
+
+			if it.Next() {
+				t.Errorf("TradeIterator.Next() = true after the iteration finished")
+			}
+		})
+	}
+}
+
+func TestTradeIteratorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	it := NewTradeIterator(NewClient(WithBaseUrl(srv.URL), WithBookCatalog(0)), BTC_MXN, time.Time{})
+	if it.Next() {
+		t.Errorf("TradeIterator.Next() = true, want false")
+	}
+
+	if it.Err() == nil {
+		t.Errorf("TradeIterator.Err() = nil, want an error")
+	}
+}
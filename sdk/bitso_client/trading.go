@@ -0,0 +1,350 @@
+package bitso_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/umarquez/cryptocoins-go-challenge/sdk/decimal"
+)
+
+var (
+	// ErrInvalidOrder represents an error when an order request is malformed.
+	ErrInvalidOrder = errors.New("invalid order")
+	// ErrAmountOutOfRange represents an error when the amount of an order is
+	// out of the limits of the book.
+	ErrAmountOutOfRange = errors.New("amount out of range")
+	// ErrPriceOutOfRange represents an error when the price of an order is
+	// out of the limits of the book or is not a multiple of its tick size.
+	ErrPriceOutOfRange = errors.New("price out of range")
+	// ErrValueOutOfRange represents an error when the value of an order is
+	// out of the limits of the book.
+	ErrValueOutOfRange = errors.New("value out of range")
+	// ErrInsufficientFunds represents an error when the balance of the user
+	// is too low to place an order.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrOrderNotFound represents an error when an order doesn't exist.
+	ErrOrderNotFound = errors.New("order not found")
+)
+
+// OrderSide represents the side of an order.
+type OrderSide string
+
+const (
+	Buy  OrderSide = "buy"  // Buy for orders buying the major currency.
+	Sell OrderSide = "sell" // Sell for orders selling the major currency.
+)
+
+// OrderType represents the type of an order.
+type OrderType string
+
+const (
+	MarketOrder OrderType = "market" // MarketOrder for orders filled at the best available price.
+	LimitOrder  OrderType = "limit"  // LimitOrder for orders filled at the given price or better.
+)
+
+// TimeInForce represents how long a limit order remains active.
+type TimeInForce string
+
+const (
+	GoodTillCancelled TimeInForce = "goodtillcancelled" // GoodTillCancelled for orders active until cancelled.
+	FillOrKill        TimeInForce = "fillorkill"        // FillOrKill for orders filled completely or cancelled.
+	ImmediateOrCancel TimeInForce = "immediateorcancel" // ImmediateOrCancel for orders cancelled after the immediate fill.
+	PostOnly          TimeInForce = "postonly"          // PostOnly for orders cancelled if they would take liquidity.
+)
+
+// OrderRequest represents the parameters of a new order.
+// Exactly one of Major (amount in the major currency) or Minor (amount in
+// the minor currency) must be set. Price and TimeInForce only apply to
+// limit orders. ClientId is an optional unique id chosen by the user.
+// The amounts and the price are strings, as sent to the API, so an unset
+// field is omitted rather than sent as zero; format them with
+// decimal.Decimal.String when computed.
+type OrderRequest struct {
+	Book        TickerName  `json:"book"`
+	Side        OrderSide   `json:"side"`
+	Type        OrderType   `json:"type"`
+	Major       string      `json:"major,omitempty"`
+	Minor       string      `json:"minor,omitempty"`
+	Price       string      `json:"price,omitempty"`
+	TimeInForce TimeInForce `json:"time_in_force,omitempty"`
+	ClientId    string      `json:"origin_id,omitempty"`
+}
+
+type bitsoPlaceOrderResponse struct {
+	bitsoBaseResponse
+	Payload struct {
+		Oid string `json:"oid"`
+	} `json:"payload"`
+}
+
+type bitsoCancelOrderResponse struct {
+	bitsoBaseResponse
+	Payload []string `json:"payload"`
+}
+
+// TradingClient represents the Bitso client with access to the private and
+// trading endpoints.
+// Orders are validated locally against the limits of their book before
+// being sent, so invalid orders fail without a network round trip.
+type TradingClient interface {
+	PrivateClient
+	ValidateOrder(req OrderRequest) error
+	ValidateOrderContext(ctx context.Context, req OrderRequest) error
+	PlaceOrder(req OrderRequest) (string, error)
+	PlaceOrderContext(ctx context.Context, req OrderRequest) (string, error)
+	CancelOrder(oid string) error
+	CancelOrderContext(ctx context.Context, oid string) error
+	CancelAll() ([]string, error)
+	CancelAllContext(ctx context.Context) ([]string, error)
+	LookupOrder(oids ...string) ([]Order, error)
+	LookupOrderContext(ctx context.Context, oids ...string) ([]Order, error)
+}
+
+// NewTradingClient creates a new instance of the Bitso client that can place
+// and cancel orders, signing the requests with the given credentials.
+// See NewClient for the available options.
+// Example:
+//
+//	client := NewTradingClient(NewEnvCredentials())
+//	oid, err := client.PlaceOrder(OrderRequest{
+//		Book:  BTC_MXN,
+//		Side:  Buy,
+//		Type:  LimitOrder,
+//		Major: "0.001",
+//		Price: "500000.00",
+//	})
+//	if errors.Is(err, ErrInsufficientFunds) {
+//		log.Fatal("not enough MXN")
+//	}
+func NewTradingClient(credentials CredentialsProvider, opts ...Option) TradingClient {
+	return NewPrivateClient(credentials, opts...).(*bitsoClient)
+}
+
+// orderAmountScale is the number of decimals of the amounts computed from
+// the value and price of an order, the precision of the crypto amounts.
+const orderAmountScale = 8
+
+// parsePositive parses a positive decimal number.
+func parsePositive(name, value string) (decimal.Decimal, error) {
+	d, err := decimal.Parse(value)
+	if err != nil || d.Sign() <= 0 {
+		return decimal.Zero, fmt.Errorf("%w: %s must be a positive number, got %q", ErrInvalidOrder, name, value)
+	}
+
+	return d, nil
+}
+
+// checkRange checks min <= value <= max, ignoring a max that is not set.
+func checkRange(kind error, name string, value, min, max decimal.Decimal) error {
+	if value.Cmp(min) < 0 {
+		return fmt.Errorf("%w: %s %s is below the minimum %s", kind, name, value, min)
+	}
+
+	if max.Sign() > 0 && value.Cmp(max) > 0 {
+		return fmt.Errorf("%w: %s %s is above the maximum %s", kind, name, value, max)
+	}
+
+	return nil
+}
+
+// validateOrder checks the order request against the limits of its book.
+func (c *bitsoClient) validateOrder(ctx context.Context, req OrderRequest) error {
+	book, err := c.GetBookContext(ctx, req.Book)
+	if err != nil {
+		return err
+	}
+
+	if req.Side != Buy && req.Side != Sell {
+		return fmt.Errorf("%w: unknown side %q", ErrInvalidOrder, req.Side)
+	}
+
+	if (req.Major == "") == (req.Minor == "") {
+		return fmt.Errorf("%w: exactly one of major or minor must be set", ErrInvalidOrder)
+	}
+
+	var price decimal.Decimal
+	switch req.Type {
+	case MarketOrder:
+		if req.Price != "" || req.TimeInForce != "" {
+			return fmt.Errorf("%w: market orders don't accept price nor time in force", ErrInvalidOrder)
+		}
+	case LimitOrder:
+		if price, err = parsePositive("price", req.Price); err != nil {
+			return err
+		}
+
+		if err = checkRange(ErrPriceOutOfRange, "price", price, book.MinimumPrice, book.MaximumPrice); err != nil {
+			return err
+		}
+
+		if !price.IsMultipleOf(book.TickSize) {
+			return fmt.Errorf("%w: price %s is not a multiple of the tick size %s", ErrPriceOutOfRange, req.Price, book.TickSize)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidOrder, req.Type)
+	}
+
+	// amount is in the major currency and value in the minor currency; both
+	// are known for limit orders, only one of them for market orders.
+	var amount, value decimal.Decimal
+	if req.Major != "" {
+		if amount, err = parsePositive("major", req.Major); err != nil {
+			return err
+		}
+		if !price.IsZero() {
+			value = amount.Mul(price)
+		}
+	} else {
+		if value, err = parsePositive("minor", req.Minor); err != nil {
+			return err
+		}
+		if !price.IsZero() {
+			amount = value.Div(price, orderAmountScale, decimal.RoundDown)
+		}
+	}
+
+	if !amount.IsZero() {
+		if err = checkRange(ErrAmountOutOfRange, "amount", amount, book.MinimumAmount, book.MaximumAmount); err != nil {
+			return err
+		}
+	}
+
+	if !value.IsZero() {
+		if err = checkRange(ErrValueOutOfRange, "value", value, book.MinimumValue, book.MaximumValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// placeOrder calls `POST <bitsoBaseUrl>/orders` to place an order and
+// returns its oid if "success" == true.
+// Otherwise, it returns an error.
+// ref: https://docs.bitso.com/bitso-api/docs/place-an-order
+func (c *bitsoClient) placeOrder(ctx context.Context, req OrderRequest) (string, error) {
+	if err := c.validateOrder(ctx, req); err != nil {
+		return "", fmt.Errorf("failed to place order: %w", err)
+	}
+
+	var resp bitsoPlaceOrderResponse
+	if err := c.do(ctx, http.MethodPost, "/orders", nil, req, true, &resp); err != nil {
+		return "", fmt.Errorf("failed to place order: %w", err)
+	}
+
+	return resp.Payload.Oid, nil
+}
+
+// cancelOrders calls `DELETE <bitsoBaseUrl>/orders/<oid>` to cancel the
+// given order, or every open order if oid is "all", and returns the oids of
+// the cancelled orders if "success" == true.
+// Otherwise, it returns an error.
+// ref: https://docs.bitso.com/bitso-api/docs/cancel-an-order
+func (c *bitsoClient) cancelOrders(ctx context.Context, oid string) ([]string, error) {
+	var resp bitsoCancelOrderResponse
+	if err := c.do(ctx, http.MethodDelete, "/orders/"+url.PathEscape(oid), nil, nil, true, &resp); err != nil {
+		return nil, fmt.Errorf("failed to cancel orders: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+// lookupOrders calls `<bitsoBaseUrl>/orders/<oid>,<oid>` to retrieve the
+// given orders and returns the result if "success" == true.
+// Otherwise, it returns an error.
+// ref: https://docs.bitso.com/bitso-api/docs/look-up-orders
+func (c *bitsoClient) lookupOrders(ctx context.Context, oids []string) ([]Order, error) {
+	if len(oids) == 0 {
+		return nil, fmt.Errorf("failed to look up orders: %w: no oids given", ErrInvalidOrder)
+	}
+
+	escaped := make([]string, len(oids))
+	for i, oid := range oids {
+		escaped[i] = url.PathEscape(oid)
+	}
+
+	var resp bitsoOrdersResponse
+	if err := c.do(ctx, http.MethodGet, "/orders/"+strings.Join(escaped, ","), nil, nil, true, &resp); err != nil {
+		return nil, fmt.Errorf("failed to look up orders: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+// ValidateOrder checks the order request against the limits of its book
+// without placing it. It returns ErrUnknownBook, ErrInvalidOrder,
+// ErrAmountOutOfRange, ErrPriceOutOfRange or ErrValueOutOfRange when the
+// order would be rejected.
+func (c *bitsoClient) ValidateOrder(req OrderRequest) error {
+	return c.ValidateOrderContext(context.Background(), req)
+}
+
+// ValidateOrderContext checks the order request against the limits of its
+// book, canceling the catalog refresh (if any) when ctx is done.
+func (c *bitsoClient) ValidateOrderContext(ctx context.Context, req OrderRequest) error {
+	return c.validateOrder(ctx, req)
+}
+
+// PlaceOrder validates and places an order, returning its oid.
+// Besides the errors of ValidateOrder, it returns ErrInsufficientFunds when
+// the balance of the user is too low.
+func (c *bitsoClient) PlaceOrder(req OrderRequest) (string, error) {
+	return c.PlaceOrderContext(context.Background(), req)
+}
+
+// PlaceOrderContext validates and places an order, canceling the request
+// when ctx is done.
+func (c *bitsoClient) PlaceOrderContext(ctx context.Context, req OrderRequest) (string, error) {
+	return c.placeOrder(ctx, req)
+}
+
+// CancelOrder cancels the given order.
+// It returns ErrOrderNotFound if the order doesn't exist or is not open.
+func (c *bitsoClient) CancelOrder(oid string) error {
+	return c.CancelOrderContext(context.Background(), oid)
+}
+
+// CancelOrderContext cancels the given order, canceling the request when
+// ctx is done.
+func (c *bitsoClient) CancelOrderContext(ctx context.Context, oid string) error {
+	if oid == "" || oid == "all" {
+		return fmt.Errorf("failed to cancel order: %w: invalid oid %q", ErrInvalidOrder, oid)
+	}
+
+	cancelled, err := c.cancelOrders(ctx, oid)
+	if err != nil {
+		return err
+	}
+
+	if len(cancelled) == 0 {
+		return fmt.Errorf("failed to cancel order: %w: %s", ErrOrderNotFound, oid)
+	}
+
+	return nil
+}
+
+// CancelAll cancels every open order of the user, returning their oids.
+func (c *bitsoClient) CancelAll() ([]string, error) {
+	return c.CancelAllContext(context.Background())
+}
+
+// CancelAllContext cancels every open order of the user, canceling the
+// request when ctx is done.
+func (c *bitsoClient) CancelAllContext(ctx context.Context) ([]string, error) {
+	return c.cancelOrders(ctx, "all")
+}
+
+// LookupOrder retrieves the given orders of the user.
+func (c *bitsoClient) LookupOrder(oids ...string) ([]Order, error) {
+	return c.LookupOrderContext(context.Background(), oids...)
+}
+
+// LookupOrderContext retrieves the given orders of the user, canceling the
+// request when ctx is done.
+func (c *bitsoClient) LookupOrderContext(ctx context.Context, oids ...string) ([]Order, error) {
+	return c.lookupOrders(ctx, oids)
+}
@@ -0,0 +1,318 @@
+package bitso_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeExchange represents a minimal Bitso exchange holding a MXN balance and
+// the orders placed on the btc_mxn book.
+type fakeExchange struct {
+	mutex     sync.Mutex
+	lastNonce int64
+	balance   *big.Rat
+	orders    map[string]*Order
+	placed    int
+	fault     *exchangeFault // fault is returned to the next signed request.
+}
+
+// exchangeFault represents an error returned by the fake exchange.
+type exchangeFault struct {
+	status  int
+	code    string
+	message string
+}
+
+func newFakeExchange(balance string) (*fakeExchange, *httptest.Server) {
+	b, _ := new(big.Rat).SetString(balance)
+	ex := &fakeExchange{balance: b, orders: make(map[string]*Order)}
+	return ex, httptest.NewServer(ex)
+}
+
+func (ex *fakeExchange) reply(w http.ResponseWriter, status int, v any) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (ex *fakeExchange) fail(w http.ResponseWriter, status int, code, message string) {
+	ex.reply(w, status, map[string]any{
+		"success": false,
+		"error":   map[string]string{"code": code, "message": message},
+	})
+}
+
+func (ex *fakeExchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ex.mutex.Lock()
+	defer ex.mutex.Unlock()
+
+	if r.URL.Path == "/available_books" {
+		_, _ = w.Write([]byte(availableBooksResponse))
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	if !verifySignature(w, r, body, &ex.lastNonce) {
+		return
+	}
+
+	if f := ex.fault; f != nil {
+		ex.fault = nil
+		ex.fail(w, f.status, f.code, f.message)
+		return
+	}
+
+	oids := strings.TrimPrefix(r.URL.Path, "/orders/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/orders":
+		var req OrderRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			ex.fail(w, http.StatusBadRequest, "0102", "Invalid JSON payload")
+			return
+		}
+
+		major, _ := new(big.Rat).SetString(req.Major)
+		price, _ := new(big.Rat).SetString(req.Price)
+		cost := new(big.Rat).Mul(major, price)
+		if req.Side == Buy && cost.Cmp(ex.balance) > 0 {
+			ex.fail(w, http.StatusBadRequest, "0379", "Insufficient funds")
+			return
+		}
+		ex.balance.Sub(ex.balance, cost)
+
+		ex.placed++
+		oid := fmt.Sprintf("oid%d", ex.placed)
+		ex.orders[oid] = &Order{
+			Oid: oid, Book: string(req.Book), OriginalAmount: req.Major, UnfilledAmount: req.Major,
+			Price: req.Price, Side: string(req.Side), Type: string(req.Type), Status: "open",
+			ClientId: req.ClientId,
+		}
+		ex.reply(w, http.StatusOK, map[string]any{"success": true, "payload": map[string]string{"oid": oid}})
+	case r.Method == http.MethodDelete && oids == "all":
+		cancelled := []string{}
+		for oid, o := range ex.orders {
+			if o.Status == "open" {
+				o.Status = "cancelled"
+				cancelled = append(cancelled, oid)
+			}
+		}
+		ex.reply(w, http.StatusOK, map[string]any{"success": true, "payload": cancelled})
+	case r.Method == http.MethodDelete:
+		o, ok := ex.orders[oids]
+		if !ok || o.Status != "open" {
+			ex.fail(w, http.StatusNotFound, "0404", "Order not found")
+			return
+		}
+		o.Status = "cancelled"
+		ex.reply(w, http.StatusOK, map[string]any{"success": true, "payload": []string{oids}})
+	case r.Method == http.MethodGet:
+		found := []Order{}
+		for _, oid := range strings.Split(oids, ",") {
+			if o, ok := ex.orders[oid]; ok {
+				found = append(found, *o)
+			}
+		}
+		ex.reply(w, http.StatusOK, map[string]any{"success": true, "payload": found})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestPlaceOrderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     OrderRequest
+		wantErr error
+	}{
+		{
+			name:    "unknown book",
+			req:     OrderRequest{Book: "btc_btc", Side: Buy, Type: LimitOrder, Major: "0.01", Price: "5000.00"},
+			wantErr: ErrUnknownBook,
+		},
+		{
+			name:    "unknown side",
+			req:     OrderRequest{Book: BTC_MXN, Side: "hold", Type: LimitOrder, Major: "0.01", Price: "5000.00"},
+			wantErr: ErrInvalidOrder,
+		},
+		{
+			name:    "major and minor",
+			req:     OrderRequest{Book: BTC_MXN, Side: Buy, Type: MarketOrder, Major: "0.01", Minor: "50"},
+			wantErr: ErrInvalidOrder,
+		},
+		{
+			name:    "market order with price",
+			req:     OrderRequest{Book: BTC_MXN, Side: Buy, Type: MarketOrder, Major: "0.01", Price: "5000.00"},
+			wantErr: ErrInvalidOrder,
+		},
+		{
+			name:    "limit order without price",
+			req:     OrderRequest{Book: BTC_MXN, Side: Sell, Type: LimitOrder, Major: "0.01"},
+			wantErr: ErrInvalidOrder,
+		},
+		{
+			name:    "price below minimum",
+			req:     OrderRequest{Book: BTC_MXN, Side: Buy, Type: LimitOrder, Major: "0.5", Price: "99.99"},
+			wantErr: ErrPriceOutOfRange,
+		},
+		{
+			name:    "price not a multiple of the tick size",
+			req:     OrderRequest{Book: BTC_MXN, Side: Buy, Type: LimitOrder, Major: "0.01", Price: "5000.005"},
+			wantErr: ErrPriceOutOfRange,
+		},
+		{
+			name:    "amount below minimum",
+			req:     OrderRequest{Book: BTC_MXN, Side: Sell, Type: MarketOrder, Major: "0.001"},
+			wantErr: ErrAmountOutOfRange,
+		},
+		{
+			name:    "amount from minor above maximum",
+			req:     OrderRequest{Book: BTC_MXN, Side: Buy, Type: LimitOrder, Minor: "150000", Price: "100.00"},
+			wantErr: ErrAmountOutOfRange,
+		},
+		{
+			name:    "value below minimum",
+			req:     OrderRequest{Book: BTC_MXN, Side: Buy, Type: LimitOrder, Major: "0.004", Price: "5000.00"},
+			wantErr: ErrValueOutOfRange,
+		},
+		{
+			name: "valid limit order",
+			req: OrderRequest{Book: BTC_MXN, Side: Buy, Type: LimitOrder, Major: "0.01", Price: "5000.00",
+				TimeInForce: PostOnly},
+		},
+		{
+			name: "valid market order",
+			req:  OrderRequest{Book: BTC_MXN, Side: Buy, Type: MarketOrder, Minor: "100"},
+		},
+	}
+
+	ex, srv := newFakeExchange("1000000")
+	defer srv.Close()
+
+	client := NewTradingClient(NewStaticCredentials(testApiKey, testApiSecret), WithBaseUrl(srv.URL))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.ValidateOrder(tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateOrder() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil {
+				return
+			}
+
+			placedBefore := ex.placed
+			if _, err = client.PlaceOrder(tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("PlaceOrder() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if ex.placed != placedBefore {
+				t.Errorf("PlaceOrder() sent an invalid order to the exchange")
+			}
+		})
+	}
+}
+
+func TestTradingClient(t *testing.T) {
+	_, srv := newFakeExchange("100.00")
+	defer srv.Close()
+
+	client := NewTradingClient(NewStaticCredentials(testApiKey, testApiSecret), WithBaseUrl(srv.URL))
+
+	oid, err := client.PlaceOrder(OrderRequest{
+		Book: BTC_MXN, Side: Buy, Type: LimitOrder, Major: "0.01", Price: "5000.00", ClientId: "my-order-1",
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	// 0.01 * 5000 = 50 MXN are locked, so another order of 100 MXN fails.
+	_, err = client.PlaceOrder(OrderRequest{Book: BTC_MXN, Side: Buy, Type: LimitOrder, Major: "0.02", Price: "5000.00"})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("PlaceOrder() error = %v, want %v", err, ErrInsufficientFunds)
+	}
+
+	second, err := client.PlaceOrder(OrderRequest{Book: BTC_MXN, Side: Sell, Type: LimitOrder, Major: "0.01", Price: "6000.00"})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	orders, err := client.LookupOrder(oid, second)
+	if err != nil {
+		t.Fatalf("LookupOrder() error = %v", err)
+	}
+	if len(orders) != 2 || orders[0].Oid != oid || orders[0].ClientId != "my-order-1" || orders[0].Status != "open" {
+		t.Errorf("LookupOrder() = %+v", orders)
+	}
+
+	if err = client.CancelOrder(oid); err != nil {
+		t.Errorf("CancelOrder() error = %v", err)
+	}
+
+	if err = client.CancelOrder(oid); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("CancelOrder() error = %v, want %v", err, ErrOrderNotFound)
+	}
+
+	cancelled, err := client.CancelAll()
+	if err != nil {
+		t.Fatalf("CancelAll() error = %v", err)
+	}
+	if len(cancelled) != 1 || cancelled[0] != second {
+		t.Errorf("CancelAll() = %v, want [%s]", cancelled, second)
+	}
+
+	if _, err = client.LookupOrder(); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("LookupOrder() error = %v, want %v", err, ErrInvalidOrder)
+	}
+}
+
+func TestTradingClientErrorCodes(t *testing.T) {
+	ex, srv := newFakeExchange("1000000")
+	defer srv.Close()
+
+	client := NewTradingClient(NewStaticCredentials(testApiKey, testApiSecret), WithBaseUrl(srv.URL))
+	order := OrderRequest{Book: BTC_MXN, Side: Buy, Type: LimitOrder, Major: "0.01", Price: "5000.00"}
+
+	// The Bitso error codes are classified whatever the message.
+	tests := []struct {
+		fault   exchangeFault
+		wantErr error
+	}{
+		{fault: exchangeFault{http.StatusBadRequest, "0379", "Insufficient funds"}, wantErr: ErrInsufficientFunds},
+		{fault: exchangeFault{http.StatusBadRequest, "0322", "Amount below the minimum"}, wantErr: ErrAmountOutOfRange},
+		{fault: exchangeFault{http.StatusBadRequest, "0323", "Amount above the maximum"}, wantErr: ErrAmountOutOfRange},
+		{fault: exchangeFault{http.StatusBadRequest, "0324", "Price below the minimum"}, wantErr: ErrPriceOutOfRange},
+		{fault: exchangeFault{http.StatusBadRequest, "0325", "Precio fuera de rango"}, wantErr: ErrPriceOutOfRange},
+		{fault: exchangeFault{http.StatusBadRequest, "0301", "Unknown OrderBook"}, wantErr: ErrUnknownBook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fault.code, func(t *testing.T) {
+			ex.fault = &tt.fault
+			if _, err := client.PlaceOrder(order); !errors.Is(err, tt.wantErr) || Retryable(err) {
+				t.Errorf("PlaceOrder() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	for _, fault := range []exchangeFault{
+		{http.StatusNotFound, "0404", "Order not found"},
+		{http.StatusBadRequest, "0405", "Order already cancelled"},
+	} {
+		ex.fault = &fault
+		if err := client.CancelOrder("oid1"); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("CancelOrder() with code %s error = %v, want %v", fault.code, err, ErrOrderNotFound)
+		}
+	}
+
+	// A message alone isn't classified.
+	ex.fault = &exchangeFault{http.StatusBadRequest, "0101", "Insufficient funds"}
+	if _, err := client.PlaceOrder(order); err == nil || errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("PlaceOrder() error = %v, want an unclassified error", err)
+	}
+}
@@ -0,0 +1,413 @@
+// Description: This package provides an http.RoundTripper that records the
+// HTTP interactions of a client into a cassette file and replays them later,
+// so the SDK and service tests can run offline and deterministically.
+// A cassette is a JSON file, usually kept under the `testdata/` directory of
+// the package that uses it. The auth headers of Bitso and the other price
+// providers, and the ones added with WithScrubbedHeaders, are scrubbed before
+// saving it.
+// Example:
+//
+//	mode, err := cassette.ModeFromEnv()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	rec, err := cassette.New("testdata/ticker.json", mode)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	client := bitso_client.NewClient(bitso_client.WithTransport(rec))
+//
+// author: Uriel Marquez (uriel.marquez@wizeline.com)
+// version: 0.1.0
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrInteractionNotFound represents an error when a request doesn't match any
+// of the interactions of the cassette in replay mode.
+var ErrInteractionNotFound = errors.New("interaction not found in cassette")
+
+// ModeEnv is the environment variable read by ModeFromEnv.
+const ModeEnv = "CASSETTE_MODE"
+
+// scrubbedValue replaces the values of the scrubbed headers and query params.
+const scrubbedValue = "REDACTED"
+
+// defaultScrubbedHeaders holds the headers that carry credentials: the
+// Bitso signature, the sessions and the API keys of the price providers.
+var defaultScrubbedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Cg-Pro-Api-Key",
+	"X-Cg-Demo-Api-Key",
+	"X-Cmc_pro_api_key",
+}
+
+// Mode represents how the Recorder handles the requests.
+type Mode int
+
+const (
+	ModeReplay      Mode = iota // ModeReplay for serving the requests from the cassette, without network access.
+	ModeRecord                  // ModeRecord for sending the requests and saving the interactions in the cassette.
+	ModePassthrough             // ModePassthrough for sending the requests without using the cassette.
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	case ModePassthrough:
+		return "passthrough"
+	}
+
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// ParseMode parses the name of a mode: "replay", "record" or "passthrough".
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "replay":
+		return ModeReplay, nil
+	case "record":
+		return ModeRecord, nil
+	case "passthrough":
+		return ModePassthrough, nil
+	}
+
+	return ModeReplay, fmt.Errorf("unknown cassette mode %q", s)
+}
+
+// ModeFromEnv returns the mode set in the CASSETTE_MODE environment
+// variable, or ModeReplay if it is not set, so the tests run offline by
+// default. It returns an error if the mode is unknown (ex. a typo), rather
+// than silently replaying.
+// Example:
+//
+//	CASSETTE_MODE=record go test ./...
+func ModeFromEnv() (Mode, error) {
+	name := os.Getenv(ModeEnv)
+	if name == "" {
+		return ModeReplay, nil
+	}
+
+	return ParseMode(name)
+}
+
+// Request represents a recorded request.
+type Request struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response represents a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Interaction represents a request and the response it got.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette represents the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Matcher reports whether a request matches a recorded one. The request URL
+// and headers are scrubbed before matching.
+type Matcher func(r *http.Request, body []byte, recorded Request) bool
+
+// DefaultMatcher matches the requests by method, URL and body.
+func DefaultMatcher(r *http.Request, body []byte, recorded Request) bool {
+	return r.Method == recorded.Method && r.URL.String() == recorded.Url && string(body) == recorded.Body
+}
+
+// Recorder represents an http.RoundTripper that records or replays the
+// interactions of a cassette.
+// All the methods are safe for concurrent use.
+type Recorder struct {
+	path           string
+	mode           Mode
+	transport      http.RoundTripper
+	matcher        Matcher
+	scrubHeaders   []string
+	scrubQueryKeys []string
+
+	mutex    sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// Option configures the Recorder created by New.
+type Option func(*Recorder)
+
+// WithTransport sets the RoundTripper used to send the requests in record
+// and passthrough modes. By default, http.DefaultTransport is used.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithMatcher sets how the requests are matched with the recorded ones.
+func WithMatcher(matcher Matcher) Option {
+	return func(r *Recorder) {
+		r.matcher = matcher
+	}
+}
+
+// WithScrubbedHeaders adds headers whose values are replaced before saving
+// the cassette, besides the default auth headers.
+func WithScrubbedHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		r.scrubHeaders = append(r.scrubHeaders, names...)
+	}
+}
+
+// WithScrubbedQueryParams sets query params whose values are replaced before
+// saving the cassette (ex. API keys sent in the URL).
+func WithScrubbedQueryParams(names ...string) Option {
+	return func(r *Recorder) {
+		r.scrubQueryKeys = append(r.scrubQueryKeys, names...)
+	}
+}
+
+// New creates a Recorder of the cassette at the given path. In replay mode
+// the cassette must exist; in record mode it is overwritten by Stop.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:         path,
+		mode:         mode,
+		transport:    http.DefaultTransport,
+		matcher:      DefaultMatcher,
+		scrubHeaders: append([]string(nil), defaultScrubbedHeaders...),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+
+		if err = json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Mode returns the mode of the recorder.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch r.mode {
+	case ModePassthrough:
+		return r.transport.RoundTrip(req)
+	case ModeRecord:
+		return r.record(req)
+	}
+
+	return r.replay(req)
+}
+
+// Stop saves the recorded interactions in record mode. It does nothing in
+// the rest of the modes.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	if err = os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// record sends the request and keeps the scrubbed interaction.
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			Url:     r.scrubUrl(req.URL).String(),
+			Headers: r.scrubHeadersOf(req.Header),
+			Body:    string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    r.scrubHeadersOf(resp.Header),
+			Body:       string(respBody),
+		},
+	}
+
+	r.mutex.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mutex.Unlock()
+
+	return resp, nil
+}
+
+// replay serves the first unused interaction matching the request. Once
+// every match was used, the last one is served again, so polling clients
+// keep working.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	scrubbed := req.Clone(req.Context())
+	scrubbed.URL = r.scrubUrl(req.URL)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	found := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !r.matcher(scrubbed, body, interaction.Request) {
+			continue
+		}
+
+		found = i
+		if !r.used[i] {
+			break
+		}
+	}
+
+	if found < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, scrubbed.URL)
+	}
+	r.used[found] = true
+
+	recorded := r.cassette.Interactions[found].Response
+	header := recorded.Headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// scrubHeadersOf returns a copy of the headers with the credentials replaced.
+// The names are matched regardless of case, as some clients set them without
+// canonicalizing (ex. X-CMC_PRO_API_KEY).
+func (r *Recorder) scrubHeadersOf(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	for key := range scrubbed {
+		for _, name := range r.scrubHeaders {
+			if strings.EqualFold(key, name) {
+				scrubbed[key] = []string{scrubbedValue}
+				break
+			}
+		}
+	}
+
+	return scrubbed
+}
+
+// scrubUrl returns a copy of the URL with the scrubbed query params replaced.
+func (r *Recorder) scrubUrl(u *url.URL) *url.URL {
+	scrubbed := *u
+	if len(r.scrubQueryKeys) == 0 {
+		return &scrubbed
+	}
+
+	query := scrubbed.Query()
+	for _, key := range r.scrubQueryKeys {
+		if query.Has(key) {
+			query.Set(key, scrubbedValue)
+		}
+	}
+	scrubbed.RawQuery = query.Encode()
+
+	return &scrubbed
+}
+
+// readBody reads the body of the request and restores it, so it can still
+// be sent.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
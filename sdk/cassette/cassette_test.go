@@ -0,0 +1,200 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range []Mode{ModeReplay, ModeRecord, ModePassthrough} {
+		got, err := ParseMode(strings.ToUpper(mode.String()))
+		if err != nil || got != mode {
+			t.Errorf("ParseMode(%s) = %v, %v, want %v", mode, got, err, mode)
+		}
+	}
+
+	if _, err := ParseMode("rewind"); err == nil {
+		t.Error("ParseMode(rewind) expected an error")
+	}
+
+	t.Setenv(ModeEnv, "record")
+	if got, err := ModeFromEnv(); err != nil || got != ModeRecord {
+		t.Errorf("ModeFromEnv() = %v, %v, want %v", got, err, ModeRecord)
+	}
+
+	t.Setenv(ModeEnv, "")
+	if got, err := ModeFromEnv(); err != nil || got != ModeReplay {
+		t.Errorf("ModeFromEnv() = %v, %v, want %v", got, err, ModeReplay)
+	}
+
+	// A typo isn't taken for the replay mode.
+	t.Setenv(ModeEnv, "recrod")
+	if _, err := ModeFromEnv(); err == nil {
+		t.Error("ModeFromEnv() with an unknown mode expected an error")
+	}
+}
+
+func send(t *testing.T, client *http.Client, url string, body string) (int, string, error) {
+	t.Helper()
+
+	method := http.MethodGet
+	if body != "" {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bitso key:1:signature")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data), nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, r.Method+" "+r.URL.Path+" "+string(body))
+	}))
+
+	path := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+	rec, err := New(path, ModeRecord, WithScrubbedQueryParams("api_key"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	client := &http.Client{Transport: rec}
+	if _, got, err := send(t, client, srv.URL+"/ticker?api_key=secret", ""); err != nil || got != "GET /ticker " {
+		t.Fatalf("record GET = %q, %v", got, err)
+	}
+	if _, got, err := send(t, client, srv.URL+"/orders", `{"side":"buy"}`); err != nil || got != `POST /orders {"side":"buy"}` {
+		t.Fatalf("record POST = %q, %v", got, err)
+	}
+
+	if err = rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "signature") {
+		t.Errorf("cassette was not scrubbed:\n%s", data)
+	}
+
+	// Replay works without the server, and matches the scrubbed requests.
+	rec, err = New(path, ModeReplay, WithScrubbedQueryParams("api_key"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	client = &http.Client{Transport: rec}
+	for i := 0; i < 2; i++ {
+		status, got, err := send(t, client, srv.URL+"/ticker?api_key=other", "")
+		if err != nil || status != http.StatusCreated || got != "GET /ticker " {
+			t.Errorf("replay GET #%d = %d, %q, %v", i, status, got, err)
+		}
+	}
+	if _, got, err := send(t, client, srv.URL+"/orders", `{"side":"buy"}`); err != nil || got != `POST /orders {"side":"buy"}` {
+		t.Errorf("replay POST = %q, %v", got, err)
+	}
+
+	if _, _, err = send(t, client, srv.URL+"/orders", `{"side":"sell"}`); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("replay unknown request error = %v, want %v", err, ErrInteractionNotFound)
+	}
+
+	if requests != 2 {
+		t.Errorf("server got %d requests, want 2", requests)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "live")
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := New(path, ModePassthrough)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, got, err := send(t, &http.Client{Transport: rec}, srv.URL, ""); err != nil || got != "live" {
+		t.Errorf("passthrough GET = %q, %v", got, err)
+	}
+
+	if err = rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("passthrough mode wrote a cassette: %v", err)
+	}
+
+	if _, err = New(path, ModeReplay); err == nil {
+		t.Error("New() in replay mode without a cassette expected an error")
+	}
+}
+
+func TestScrubProviderHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"bitcoin":{"usd":67187.34}}`)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := New(path, ModeRecord, WithScrubbedHeaders("X-Custom-Token"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// The API keys of CoinGecko and CoinMarketCap are scrubbed by default,
+	// the added headers too.
+	headers := map[string]string{
+		"x-cg-pro-api-key":  "cg-secret",
+		"X-CMC_PRO_API_KEY": "cmc-secret",
+		"X-Custom-Token":    "custom-secret",
+	}
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/simple/price?ids=bitcoin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range headers {
+		req.Header[name] = []string{value}
+	}
+	resp, err := (&http.Client{Transport: rec}).Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if err = rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Count(string(data), scrubbedValue) != len(headers) {
+		t.Errorf("cassette was not scrubbed:\n%s", data)
+	}
+}
@@ -0,0 +1,399 @@
+// Description: This package provides an arbitrary-precision decimal number
+// type for prices, amounts and values.
+// Numbers are kept as an integer coefficient and a number of decimals, so
+// they are decoded from the API strings (ex. "12345.67") without the
+// rounding errors of float64, and encoded back with the same decimals.
+// The zero value of Decimal is 0 and ready to use; Decimal values are
+// immutable, so they can be copied and shared between goroutines.
+//
+// author: Uriel Marquez (uriel.marquez@wizeline.com)
+// version: 0.1.0
+package decimal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrSyntax represents an error when a string is not a decimal number.
+	ErrSyntax = errors.New("invalid decimal number")
+	// ErrRange represents an error when a parsed number has more decimals, or
+	// a larger exponent, than maxParseScale.
+	ErrRange = errors.New("decimal number out of range")
+)
+
+// maxParseScale is the largest number of decimals, or of trailing zeros of an
+// exponent, a parsed number may have. It bounds the memory an untrusted input
+// (ex. "1e2000000000") makes Parse allocate.
+const maxParseScale = 400
+
+// RoundingMode represents how a number is rounded when decimals are dropped.
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // RoundHalfUp rounds to the nearest, ties away from zero.
+	RoundHalfEven                     // RoundHalfEven rounds to the nearest, ties to the even neighbour.
+	RoundDown                         // RoundDown rounds towards zero (truncates).
+	RoundFloor                        // RoundFloor rounds towards negative infinity.
+	RoundCeiling                      // RoundCeiling rounds towards positive infinity.
+)
+
+var (
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+// Decimal represents the number coefficient * 10^-scale.
+type Decimal struct {
+	coefficient *big.Int // coefficient is nil for the zero value.
+	scale       int32
+}
+
+// Zero represents the number 0.
+var Zero = Decimal{}
+
+// New creates the decimal number value * 10^-scale (ex. New(1234, 2) is 12.34).
+func New(value int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(value), scale)
+}
+
+// newDecimal creates a decimal number, moving a negative scale into the
+// coefficient so the scale is always the number of decimals.
+func newDecimal(coefficient *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		coefficient.Mul(coefficient, pow10(-int64(scale)))
+		scale = 0
+	}
+
+	return Decimal{coefficient: coefficient, scale: scale}
+}
+
+// NewFromInt creates a decimal number from an integer.
+func NewFromInt(value int64) Decimal {
+	return New(value, 0)
+}
+
+// Parse parses a decimal number (ex. "-12.340" or "1.5e-3"), keeping its
+// decimals. It returns an error wrapping ErrSyntax if s is not a number, or
+// ErrRange if its scale is beyond ±maxParseScale.
+func Parse(s string) (Decimal, error) {
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exponent, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return Zero, fmt.Errorf("%w: %q", ErrSyntax, s)
+		}
+		mantissa = s[:i]
+	}
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	digits := intPart + fracPart
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Zero, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+
+	coefficient, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Zero, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+
+	scale := int64(len(fracPart)) - exponent
+	if scale > maxParseScale || scale < -maxParseScale {
+		return Zero, fmt.Errorf("%w: %q", ErrRange, s)
+	}
+
+	return newDecimal(coefficient, int32(scale)), nil
+}
+
+// MustParse is like Parse but panics if s is not a number.
+// It is intended for constants and tests.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// NewFromRat creates a decimal number from a rational number, rounded to the
+// given number of decimals.
+func NewFromRat(r *big.Rat, scale int32, mode RoundingMode) Decimal {
+	num, den := new(big.Int).Set(r.Num()), new(big.Int).Set(r.Denom())
+	if scale >= 0 {
+		num.Mul(num, pow10(int64(scale)))
+	} else {
+		den.Mul(den, pow10(-int64(scale)))
+	}
+
+	return newDecimal(roundQuo(num, den, mode), scale)
+}
+
+// int returns the coefficient, which is never nil.
+func (d Decimal) int() *big.Int {
+	if d.coefficient == nil {
+		return new(big.Int)
+	}
+
+	return d.coefficient
+}
+
+// Scale returns the number of decimals of d.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{coefficient: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{coefficient: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Add returns d + x, with the decimals of the most precise operand.
+func (d Decimal) Add(x Decimal) Decimal {
+	a, b, scale := align(d, x)
+	return Decimal{coefficient: a.Add(a, b), scale: scale}
+}
+
+// Sub returns d - x, with the decimals of the most precise operand.
+func (d Decimal) Sub(x Decimal) Decimal {
+	a, b, scale := align(d, x)
+	return Decimal{coefficient: a.Sub(a, b), scale: scale}
+}
+
+// Mul returns d * x, with the decimals of both operands.
+func (d Decimal) Mul(x Decimal) Decimal {
+	return Decimal{coefficient: new(big.Int).Mul(d.int(), x.int()), scale: d.scale + x.scale}
+}
+
+// Div returns d / x rounded to the given number of decimals.
+// It panics if x is 0.
+func (d Decimal) Div(x Decimal, scale int32, mode RoundingMode) Decimal {
+	if x.IsZero() {
+		panic("decimal: division by zero")
+	}
+
+	// d / x * 10^scale = d.coefficient * 10^(scale - d.scale + x.scale) / x.coefficient
+	num, den := new(big.Int).Set(d.int()), new(big.Int).Set(x.int())
+	if exp := int64(scale) - int64(d.scale) + int64(x.scale); exp >= 0 {
+		num.Mul(num, pow10(exp))
+	} else {
+		den.Mul(den, pow10(-exp))
+	}
+
+	return newDecimal(roundQuo(num, den, mode), scale)
+}
+
+// Cmp compares d and x and returns -1 if d < x, 0 if d == x or +1 if d > x.
+func (d Decimal) Cmp(x Decimal) int {
+	a, b, _ := align(d, x)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and x are the same number, regardless of their
+// decimals (ex. 1.5 and 1.50 are equal).
+func (d Decimal) Equal(x Decimal) bool {
+	return d.Cmp(x) == 0
+}
+
+// Round returns d rounded to the given number of decimals; a negative scale
+// rounds to tens, hundreds, etc. Numbers with fewer decimals are returned as is.
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if scale >= d.scale {
+		return d
+	}
+
+	return newDecimal(roundQuo(d.int(), pow10(int64(d.scale)-int64(scale)), mode), scale)
+}
+
+// RoundToStep returns d rounded to a multiple of step (ex. the tick size of
+// a book), with the decimals of step. A step <= 0 returns d as is.
+func (d Decimal) RoundToStep(step Decimal, mode RoundingMode) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+
+	return d.Div(step, 0, mode).Mul(step)
+}
+
+// IsMultipleOf reports whether d is a multiple of step.
+// Every number is a multiple of a step <= 0.
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	if step.Sign() <= 0 {
+		return true
+	}
+
+	a, b, _ := align(d, step)
+	return a.Rem(a, b).Sign() == 0
+}
+
+// Rat returns d as a rational number.
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.int(), pow10(int64(d.scale)))
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// String returns d with its decimals (ex. "12345.670").
+func (d Decimal) String() string {
+	if d.scale == 0 {
+		return d.int().String()
+	}
+
+	digits := new(big.Int).Abs(d.int()).String()
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	split := len(digits) - int(d.scale)
+
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// StringFixed returns d rounded half up to the given number of decimals,
+// padded with zeros if needed (ex. StringFixed(2) of 1.5 is "1.50").
+func (d Decimal) StringFixed(scale int32) string {
+	r := d.Round(scale, RoundHalfUp)
+	if r.scale < scale {
+		r = Decimal{coefficient: new(big.Int).Mul(r.int(), pow10(int64(scale-r.scale))), scale: scale}
+	}
+
+	return r.String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// An empty text is decoded as 0.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Zero
+		return nil
+	}
+
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Numbers are encoded as JSON strings,
+// as the Bitso API does, so they keep their precision.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts JSON strings and
+// numbers; null and "" are decoded as 0.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Zero
+		return nil
+	}
+
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+
+	return d.UnmarshalText(data)
+}
+
+// align returns copies of the coefficients of a and b with the same scale.
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	x, y := new(big.Int).Set(a.int()), new(big.Int).Set(b.int())
+	switch {
+	case a.scale < b.scale:
+		x.Mul(x, pow10(int64(b.scale-a.scale)))
+		return x, y, b.scale
+	case a.scale > b.scale:
+		y.Mul(y, pow10(int64(a.scale-b.scale)))
+	}
+
+	return x, y, a.scale
+}
+
+// pow10 returns 10^n.
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(n), nil)
+}
+
+// roundQuo returns num / den rounded with the given mode.
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	n, dd := new(big.Int).Set(num), new(big.Int).Set(den)
+	if dd.Sign() < 0 {
+		n.Neg(n)
+		dd.Neg(dd)
+	}
+
+	q, r := new(big.Int).QuoRem(n, dd, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// q is truncated towards zero: decide whether to move it away from zero.
+	var away bool
+	switch mode {
+	case RoundDown:
+		away = false
+	case RoundFloor:
+		away = n.Sign() < 0
+	case RoundCeiling:
+		away = n.Sign() > 0
+	default:
+		// Compare the remainder against half of the divisor.
+		half := new(big.Int).Abs(r)
+		half.Lsh(half, 1)
+		switch half.Cmp(dd) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == RoundHalfUp || q.Bit(0) == 1
+		}
+	}
+
+	if away {
+		if n.Sign() < 0 {
+			q.Sub(q, bigOne)
+		} else {
+			q.Add(q, bigOne)
+		}
+	}
+
+	return q
+}
@@ -0,0 +1,230 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "0", want: "0"},
+		{input: "12345.67", want: "12345.67"},
+		{input: "12345.670", want: "12345.670"},
+		{input: "-0.001", want: "-0.001"},
+		{input: "+.5", want: "0.5"},
+		{input: "5.", want: "5"},
+		{input: "1.5e-3", want: "0.0015"},
+		{input: "1.5E3", want: "1500"},
+		{input: "123456789012345678901234567890.123456789", want: "123456789012345678901234567890.123456789"},
+		{input: "", wantErr: true},
+		{input: "-", wantErr: true},
+		{input: "1.2.3", wantErr: true},
+		{input: "1-2", wantErr: true},
+		{input: "NaN", wantErr: true},
+		{input: "1e", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if tt.wantErr {
+			if !errors.Is(err, ErrSyntax) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.input, err, ErrSyntax)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for _, input := range []string{"1e2000000000", "1e-2000000000", "1e401", "0." + strings.Repeat("0", 400) + "1"} {
+		if _, err := Parse(input); !errors.Is(err, ErrRange) {
+			t.Errorf("Parse(%.20q) error = %v, want %v", input, err, ErrRange)
+		}
+	}
+
+	for _, input := range []string{"1e400", "1e-400"} {
+		if _, err := Parse(input); err != nil {
+			t.Errorf("Parse(%q) error = %v", input, err)
+		}
+	}
+
+	// The JSON values are parsed with the same limit.
+	var v struct {
+		Price Decimal `json:"price"`
+	}
+	if err := json.Unmarshal([]byte(`{"price":"1e2000000000"}`), &v); !errors.Is(err, ErrRange) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, ErrRange)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := MustParse("0.1"), MustParse("0.2")
+	if got := a.Add(b); got.String() != "0.3" || !got.Equal(MustParse("0.30")) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", got)
+	}
+
+	if got := MustParse("10").Sub(MustParse("0.25")); got.String() != "9.75" {
+		t.Errorf("10 - 0.25 = %s, want 9.75", got)
+	}
+
+	if got := MustParse("0.001").Mul(MustParse("500000.00")); got.String() != "500.00000" {
+		t.Errorf("0.001 * 500000.00 = %s, want 500.00000", got)
+	}
+
+	if got := MustParse("1").Div(MustParse("3"), 8, RoundHalfUp); got.String() != "0.33333333" {
+		t.Errorf("1 / 3 = %s, want 0.33333333", got)
+	}
+
+	if got := MustParse("-2").Div(MustParse("3"), 2, RoundHalfUp); got.String() != "-0.67" {
+		t.Errorf("-2 / 3 = %s, want -0.67", got)
+	}
+
+	if got := Zero.Add(New(1234, 2)); got.String() != "12.34" {
+		t.Errorf("0 + 12.34 = %s, want 12.34", got)
+	}
+
+	if got := New(5, -2); got.String() != "500" {
+		t.Errorf("New(5, -2) = %s, want 500", got)
+	}
+
+	if got := NewFromRat(big.NewRat(2, 3), 4, RoundDown); got.String() != "0.6666" {
+		t.Errorf("NewFromRat(2/3) = %s, want 0.6666", got)
+	}
+
+	if got := MustParse("-1.50").Abs().Neg(); got.String() != "-1.50" {
+		t.Errorf("-|-1.50| = %s, want -1.50", got)
+	}
+
+	if f := MustParse("12345.67").Float64(); f != 12345.67 {
+		t.Errorf("Float64() = %v, want 12345.67", f)
+	}
+}
+
+func TestCmp(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1", b: "1.000", want: 0},
+		{a: "0.1", b: "0.09", want: 1},
+		{a: "-5", b: "3", want: -1},
+		{a: "0", b: "-0.0", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := MustParse(tt.a).Cmp(MustParse(tt.b)); got != tt.want {
+			t.Errorf("Cmp(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if !Zero.IsZero() || Zero.Sign() != 0 || Zero.String() != "0" {
+		t.Errorf("Zero = %s, want 0", Zero)
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		input string
+		scale int32
+		mode  RoundingMode
+		want  string
+	}{
+		{input: "2.345", scale: 2, mode: RoundHalfUp, want: "2.35"},
+		{input: "-2.345", scale: 2, mode: RoundHalfUp, want: "-2.35"},
+		{input: "2.345", scale: 2, mode: RoundHalfEven, want: "2.34"},
+		{input: "2.355", scale: 2, mode: RoundHalfEven, want: "2.36"},
+		{input: "2.349", scale: 2, mode: RoundDown, want: "2.34"},
+		{input: "-2.341", scale: 2, mode: RoundFloor, want: "-2.35"},
+		{input: "2.341", scale: 2, mode: RoundCeiling, want: "2.35"},
+		{input: "2.5", scale: 3, mode: RoundHalfUp, want: "2.5"},
+		{input: "1250", scale: -2, mode: RoundHalfUp, want: "1300"},
+	}
+
+	for _, tt := range tests {
+		if got := MustParse(tt.input).Round(tt.scale, tt.mode); got.String() != tt.want {
+			t.Errorf("Round(%s, %d, %d) = %s, want %s", tt.input, tt.scale, tt.mode, got, tt.want)
+		}
+	}
+
+	if got := MustParse("1.5").StringFixed(2); got != "1.50" {
+		t.Errorf("StringFixed(1.5, 2) = %s, want 1.50", got)
+	}
+	if got := MustParse("1.555").StringFixed(2); got != "1.56" {
+		t.Errorf("StringFixed(1.555, 2) = %s, want 1.56", got)
+	}
+}
+
+func TestRoundToStep(t *testing.T) {
+	tests := []struct {
+		input string
+		step  string
+		mode  RoundingMode
+		want  string
+	}{
+		{input: "500000.123", step: "0.01", mode: RoundHalfUp, want: "500000.12"},
+		{input: "500000.127", step: "0.01", mode: RoundDown, want: "500000.12"},
+		{input: "1234.56", step: "5", mode: RoundHalfUp, want: "1235"},
+		{input: "1234.56", step: "0.5", mode: RoundFloor, want: "1234.5"},
+		{input: "1234.56", step: "0.5", mode: RoundCeiling, want: "1235.0"},
+		{input: "1234.56", step: "0", mode: RoundHalfUp, want: "1234.56"},
+	}
+
+	for _, tt := range tests {
+		step := MustParse(tt.step)
+		got := MustParse(tt.input).RoundToStep(step, tt.mode)
+		if got.String() != tt.want {
+			t.Errorf("RoundToStep(%s, %s) = %s, want %s", tt.input, tt.step, got, tt.want)
+		}
+		if !got.IsMultipleOf(step) {
+			t.Errorf("IsMultipleOf(%s, %s) = false, want true", got, tt.step)
+		}
+	}
+
+	if MustParse("500000.125").IsMultipleOf(MustParse("0.01")) {
+		t.Error("IsMultipleOf(500000.125, 0.01) = true, want false")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Price  Decimal `json:"price"`
+		Amount Decimal `json:"amount"`
+		Value  Decimal `json:"value"`
+		Empty  Decimal `json:"empty"`
+		Null   Decimal `json:"null"`
+	}
+
+	data := `{"price":"12345.670","amount":0.5,"value":"1e2","empty":"","null":null}`
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if v.Price.String() != "12345.670" || v.Amount.String() != "0.5" || v.Value.String() != "100" ||
+		!v.Empty.IsZero() || !v.Null.IsZero() {
+		t.Errorf("Unmarshal() = %+v", v)
+	}
+
+	out, err := json.Marshal(v.Price)
+	if err != nil || string(out) != `"12345.670"` {
+		t.Errorf("Marshal() = %s, %v, want \"12345.670\"", out, err)
+	}
+
+	if err = json.Unmarshal([]byte(`{"price":"abc"}`), &v); !errors.Is(err, ErrSyntax) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, ErrSyntax)
+	}
+}